@@ -0,0 +1,111 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// SumCanonicalJSON returns the SM3 checksum of the canonical form of the
+// JSON document jsonBytes, so that documents differing only in key order,
+// whitespace or number spelling hash alike. It returns an error if
+// jsonBytes is not a single valid JSON value.
+//
+// The canonical form is the UTF-8 serialization with:
+//
+//   - no whitespace outside strings;
+//   - object members sorted by key, comparing the UTF-8 bytes of the
+//     keys; of duplicate keys only the last is kept;
+//   - numbers converted to IEEE 754 doubles and written as the shortest
+//     decimal that round-trips, in plain notation when 1e-6 <= |x| < 1e21
+//     and otherwise as d.ddde±x with no leading zeros in the exponent,
+//     as ECMAScript does; -0 is written 0;
+//   - strings with '"' and '\' escaped by a backslash, characters below
+//     U+0020 written as \u00xx with lowercase hex digits, and everything
+//     else, including non-ASCII characters, written literally; invalid
+//     UTF-8 is replaced by U+FFFD;
+//   - true, false and null as themselves.
+func SumCanonicalJSON(jsonBytes []byte) ([Size]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(jsonBytes, &v); err != nil {
+		return [Size]byte{}, err
+	}
+	return Sum(appendCanonicalJSON(nil, v)), nil
+}
+
+func appendCanonicalJSON(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case bool:
+		return strconv.AppendBool(b, v)
+	case float64:
+		return appendCanonicalNumber(b, v)
+	case string:
+		return appendCanonicalString(b, v)
+	case []interface{}:
+		b = append(b, '[')
+		for i, e := range v {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendCanonicalJSON(b, e)
+		}
+		return append(b, ']')
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = append(b, '{')
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendCanonicalString(b, k)
+			b = append(b, ':')
+			b = appendCanonicalJSON(b, v[k])
+		}
+		return append(b, '}')
+	}
+	panic("sm3: unexpected JSON value")
+}
+
+func appendCanonicalNumber(b []byte, f float64) []byte {
+	if f == 0 {
+		return append(b, '0')
+	}
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.AppendFloat(b, f, 'f', -1, 64)
+	}
+	n := len(b)
+	b = strconv.AppendFloat(b, f, 'e', -1, 64)
+	// Trim a leading zero of the exponent: 1e-07 becomes 1e-7.
+	if m := len(b); m-n >= 4 && b[m-4] == 'e' && b[m-2] == '0' {
+		b[m-2] = b[m-1]
+		b = b[:m-1]
+	}
+	return b
+}
+
+func appendCanonicalString(b []byte, s string) []byte {
+	const hexDigits = "0123456789abcdef"
+	b = append(b, '"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b = append(b, '\\', byte(r))
+		case r < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hexDigits[r>>4], hexDigits[r&0xf])
+		default:
+			b = append(b, string(r)...)
+		}
+	}
+	return append(b, '"')
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSumCanonicalJSON(t *testing.T) {
+	for _, tt := range []struct{ a, b string }{
+		{`{"b":1,"a":[true,false,null]}`, " {\n\t\"a\" : [ true , false , null ] ,\n\t\"b\" : 1 }"},
+		{`{"n":1}`, `{"n":1.0}`},
+		{`{"n":100}`, `{"n":1e2}`},
+		{`{"n":0}`, `{"n":-0}`},
+		{`"\u00e9"`, `"é"`},
+		{`{"a":1,"a":2}`, `{"a":2}`},
+	} {
+		sa, err := SumCanonicalJSON([]byte(tt.a))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.a, err)
+		}
+		sb, err := SumCanonicalJSON([]byte(tt.b))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.b, err)
+		}
+		if sa != sb {
+			t.Fatalf("%s and %s hash differently", tt.a, tt.b)
+		}
+	}
+
+	a, _ := SumCanonicalJSON([]byte(`{"a":1}`))
+	b, _ := SumCanonicalJSON([]byte(`{"a":2}`))
+	if a == b {
+		t.Fatal("different values hash alike")
+	}
+
+	for _, s := range []string{"", "{", `{"a":}`, `[1,]`, `{} {}`, `1e400`} {
+		if _, err := SumCanonicalJSON([]byte(s)); err == nil {
+			t.Fatalf("SumCanonicalJSON(%q) succeeded", s)
+		}
+	}
+}
+
+func TestCanonicalJSONForm(t *testing.T) {
+	for _, tt := range []struct{ in, out string }{
+		{` { "b" : [1, 2.50, -3e0], "a" : {"y": null, "x": true} } `, `{"a":{"x":true,"y":null},"b":[1,2.5,-3]}`},
+		{`[1e21, 1e-7, 123456789012345678901, 0.000001, -1.5E-10]`, `[1e+21,1e-7,123456789012345680000,0.000001,-1.5e-10]`},
+		{`"tab\tquote\"slash\\ \u0001   <&>"`, "\"tab\\u0009quote\\\"slash\\\\ \\u0001   <&>\""},
+		{`{"é":1,"z":2,"A":3}`, `{"A":3,"z":2,"é":1}`},
+	} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.in), &v); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(appendCanonicalJSON(nil, v)); got != tt.out {
+			t.Fatalf("%s \ngot : %s \nwant: %s", tt.in, got, tt.out)
+		}
+		if sum, _ := SumCanonicalJSON([]byte(tt.in)); sum != Sum([]byte(tt.out)) {
+			t.Fatalf("%s: SumCanonicalJSON is not the checksum of the canonical form", tt.in)
+		}
+	}
+}
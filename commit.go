@@ -0,0 +1,39 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"crypto/rand"
+	"io"
+)
+
+// randReader is the source of commitment nonces, replaced in tests.
+var randReader io.Reader = rand.Reader
+
+// Commit returns a commitment SM3(nonce || value) to value together with
+// the random 32-byte nonce needed to open it. The nonce keeps low-entropy
+// values from being recovered by guessing; it must stay secret until the
+// commitment is opened. An error is returned only if reading from
+// crypto/rand fails.
+func Commit(value []byte) (commitment [Size]byte, nonce [32]byte, err error) {
+	if _, err := io.ReadFull(randReader, nonce[:]); err != nil {
+		return [Size]byte{}, [32]byte{}, err
+	}
+	return commitTo(&nonce, value), nonce, nil
+}
+
+// VerifyCommitment reports, in constant time, whether commitment opens to
+// value with nonce.
+func VerifyCommitment(commitment [Size]byte, nonce [32]byte, value []byte) bool {
+	return Equal(commitTo(&nonce, value), commitment)
+}
+
+func commitTo(nonce *[32]byte, value []byte) [Size]byte {
+	var d digest
+	d.Reset()
+	d.Write(nonce[:])
+	d.Write(value)
+	return d.checkSum()
+}
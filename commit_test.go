@@ -0,0 +1,46 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCommit(t *testing.T) {
+	value := []byte("heads")
+	c, nonce, err := Commit(value)
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if want := Sum(append(nonce[:], value...)); c != want {
+		t.Fatalf("commitment = %x, want %x", c, want)
+	}
+	if !VerifyCommitment(c, nonce, value) {
+		t.Fatal("VerifyCommitment rejected a correct opening")
+	}
+	if VerifyCommitment(c, nonce, []byte("tails")) {
+		t.Fatal("VerifyCommitment accepted a different value")
+	}
+	bad := nonce
+	bad[0] ^= 1
+	if VerifyCommitment(c, bad, value) {
+		t.Fatal("VerifyCommitment accepted a different nonce")
+	}
+
+	c2, nonce2, _ := Commit(value)
+	if nonce2 == nonce || c2 == c {
+		t.Fatal("two commitments to the same value share a nonce")
+	}
+}
+
+func TestCommitRandError(t *testing.T) {
+	defer func(r io.Reader) { randReader = r }(randReader)
+	randReader = errReader{iotest.ErrTimeout}
+	if _, _, err := Commit([]byte("value")); err != iotest.ErrTimeout {
+		t.Fatalf("got error %v, want %v", err, iotest.ErrTimeout)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"hash"
+	"sync"
+)
+
+// lockedDigest is a digest guarded by a mutex.
+type lockedDigest struct {
+	mu sync.Mutex
+	d  digest
+}
+
+// NewConcurrent returns a new hash.Hash computing the SM3 checksum whose
+// methods may be called from multiple goroutines. Each Write is atomic,
+// but the checksum depends on the order in which concurrent writes happen,
+// so callers still have to order them themselves for a meaningful result.
+func NewConcurrent() hash.Hash {
+	l := new(lockedDigest)
+	l.d.Reset()
+	return l
+}
+
+func (l *lockedDigest) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.d.Write(p)
+}
+
+func (l *lockedDigest) Sum(b []byte) []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.d.Sum(b)
+}
+
+func (l *lockedDigest) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.d.Reset()
+}
+
+func (l *lockedDigest) Size() int { return Size }
+
+func (l *lockedDigest) BlockSize() int { return BlockSize }
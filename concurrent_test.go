@@ -0,0 +1,41 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestNewConcurrent(t *testing.T) {
+	h := NewConcurrent()
+	chunk := bytes.Repeat([]byte{'x'}, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				h.Write(chunk)
+				h.Sum(nil)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// All writes are identical, so their order does not matter.
+	want := Sum(bytes.Repeat(chunk, 16*100))
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+
+	h.Reset()
+	h.Write([]byte("abc"))
+	if got, want := h.Sum(nil), Sum([]byte("abc")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("after Reset: got %x, want %x", got, want)
+	}
+}
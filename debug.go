@@ -0,0 +1,17 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build sm3debug
+// +build sm3debug
+
+package sm3
+
+// Built with the sm3debug tag, the package checks at initialization that
+// the block function in use, which may be assembly, agrees with the
+// portable one, and panics if it does not.
+func init() {
+	if !checkBlock() {
+		panic("sm3: block disagrees with blockGeneric (" + Implementation() + ")")
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build sm3debug
+// +build sm3debug
+
+package sm3
+
+import "testing"
+
+// TestDebugCheck runs after the sm3debug init check, so reaching it means
+// the check passed; it also runs the check again directly.
+func TestDebugCheck(t *testing.T) {
+	if !checkBlock() {
+		t.Fatalf("block disagrees with blockGeneric on %s", Implementation())
+	}
+}
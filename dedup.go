@@ -0,0 +1,32 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+// A DedupHasher hashes chunks and remembers the checksums it has seen, to
+// detect duplicate chunks. It is not safe for concurrent use.
+type DedupHasher struct {
+	seen map[[Size]byte]struct{}
+}
+
+// NewDedupHasher returns a DedupHasher that has seen no chunks.
+func NewDedupHasher() *DedupHasher {
+	return &DedupHasher{seen: make(map[[Size]byte]struct{})}
+}
+
+// Add returns the SM3 checksum of chunk and whether no chunk with that
+// checksum had been added before.
+func (h *DedupHasher) Add(chunk []byte) (sum [Size]byte, isNew bool) {
+	sum = Sum(chunk)
+	if _, ok := h.seen[sum]; ok {
+		return sum, false
+	}
+	h.seen[sum] = struct{}{}
+	return sum, true
+}
+
+// Count returns the number of distinct chunks added.
+func (h *DedupHasher) Count() int {
+	return len(h.seen)
+}
@@ -0,0 +1,34 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "testing"
+
+func TestDedupHasher(t *testing.T) {
+	h := NewDedupHasher()
+	for i, tt := range []struct {
+		chunk string
+		isNew bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"a", false},
+		{"", true},
+		{"", false},
+		{"b", false},
+		{"c", true},
+	} {
+		sum, isNew := h.Add([]byte(tt.chunk))
+		if isNew != tt.isNew {
+			t.Fatalf("#%d: Add(%q) isNew = %v, want %v", i, tt.chunk, isNew, tt.isNew)
+		}
+		if want := Sum([]byte(tt.chunk)); sum != want {
+			t.Fatalf("#%d: Add(%q) \ngot : %x \nwant: %x", i, tt.chunk, sum, want)
+		}
+	}
+	if n := h.Count(); n != 4 {
+		t.Fatalf("Count = %d, want 4", n)
+	}
+}
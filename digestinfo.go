@@ -0,0 +1,30 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "encoding/asn1"
+
+// OID is the object identifier of SM3, 1.2.156.10197.1.401.
+var OID = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}
+
+// digestInfoPrefix is the DER encoding of a PKCS #1 DigestInfo up to the
+// digest itself:
+//
+//	SEQUENCE {
+//		SEQUENCE { OID 1.2.156.10197.1.401, NULL }
+//		OCTET STRING (32 bytes)
+//	}
+var digestInfoPrefix = []byte{
+	0x30, 0x30, 0x30, 0x0c, 0x06, 0x08, 0x2a, 0x81, 0x1c, 0xcf,
+	0x55, 0x01, 0x83, 0x11, 0x05, 0x00, 0x04, 0x20,
+}
+
+// DigestInfo returns the DER encoded DigestInfo of hash, as prepended to
+// the digest by PKCS #1 v1.5 signatures.
+func DigestInfo(hash [Size]byte) []byte {
+	b := make([]byte, 0, len(digestInfoPrefix)+Size)
+	b = append(b, digestInfoPrefix...)
+	return append(b, hash[:]...)
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+)
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+func TestDigestInfo(t *testing.T) {
+	sum := Sum([]byte("abc"))
+	der := DigestInfo(sum)
+
+	var info digestInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		t.Fatalf("asn1.Unmarshal: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Fatalf("trailing data after DigestInfo: %x", rest)
+	}
+	if !info.Algorithm.Algorithm.Equal(OID) {
+		t.Fatalf("algorithm = %v, want %v", info.Algorithm.Algorithm, OID)
+	}
+	if !bytes.Equal(info.Algorithm.Parameters.FullBytes, asn1.NullBytes) {
+		t.Fatalf("parameters = %x, want NULL", info.Algorithm.Parameters.FullBytes)
+	}
+	if !bytes.Equal(info.Digest, sum[:]) {
+		t.Fatalf("digest = %x, want %x", info.Digest, sum)
+	}
+
+	want, err := asn1.Marshal(digestInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: OID, Parameters: asn1.NullRawValue},
+		Digest:    sum[:],
+	})
+	if err != nil {
+		t.Fatalf("asn1.Marshal: %v", err)
+	}
+	if !bytes.Equal(der, want) {
+		t.Fatalf("DigestInfo \ngot : %x \nwant: %x", der, want)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	// drbgSeedLen is the seed length of Hash_DRBG for a 256-bit hash,
+	// 440 bits, as given in NIST SP 800-90A table 2.
+	drbgSeedLen = 55
+
+	// drbgMinEntropy is the minimum entropy input for a security
+	// strength of 256 bits.
+	drbgMinEntropy = 32
+
+	// drbgMaxRequest is the largest Generate request, 2^19 bits.
+	drbgMaxRequest = 1 << 16
+
+	// drbgReseedInterval is the number of Generate calls allowed between
+	// reseeds.
+	drbgReseedInterval = 1 << 48
+)
+
+// ErrReseedRequired is returned by Generate once the reseed interval of a
+// DRBG has been reached.
+var ErrReseedRequired = errors.New("sm3: DRBG reseed required")
+
+// A DRBG is a deterministic random bit generator following the Hash_DRBG
+// mechanism of NIST SP 800-90A with SM3 as the hash function. Identical
+// seed material produces identical output.
+type DRBG struct {
+	v, c          [drbgSeedLen]byte
+	reseedCounter uint64
+}
+
+// NewDRBG instantiates a DRBG from entropy, which must be at least 32
+// bytes, a nonce and an optional personalization string.
+func NewDRBG(entropy, nonce, personalization []byte) (*DRBG, error) {
+	if len(entropy) < drbgMinEntropy {
+		return nil, lengthError("DRBG entropy input too short")
+	}
+	d := new(DRBG)
+	hashDF(d.v[:], entropy, nonce, personalization)
+	d.update()
+	return d, nil
+}
+
+// Reseed mixes fresh entropy, at least 32 bytes, and optional additional
+// input into the state and resets the reseed counter.
+func (d *DRBG) Reseed(entropy, additional []byte) error {
+	if len(entropy) < drbgMinEntropy {
+		return lengthError("DRBG entropy input too short")
+	}
+	v := d.v
+	hashDF(d.v[:], []byte{0x01}, v[:], entropy, additional)
+	d.update()
+	return nil
+}
+
+// update derives C from a freshly seeded V.
+func (d *DRBG) update() {
+	hashDF(d.c[:], []byte{0x00}, d.v[:])
+	d.reseedCounter = 1
+}
+
+// Generate returns n pseudorandom bytes, mixing in the optional additional
+// input first. n may be at most 65536.
+func (d *DRBG) Generate(n int, additional []byte) ([]byte, error) {
+	if n < 0 || n > drbgMaxRequest {
+		return nil, lengthError("DRBG request length out of range")
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return nil, ErrReseedRequired
+	}
+
+	var h digest
+	if len(additional) > 0 {
+		h.Reset()
+		h.Write([]byte{0x02})
+		h.Write(d.v[:])
+		h.Write(additional)
+		w := h.checkSum()
+		addMod(d.v[:], w[:])
+	}
+
+	out := make([]byte, n)
+	data := d.v
+	for i := 0; i < n; {
+		h.Reset()
+		h.Write(data[:])
+		sum := h.checkSum()
+		i += copy(out[i:], sum[:])
+		addMod(data[:], []byte{1})
+	}
+
+	h.Reset()
+	h.Write([]byte{0x03})
+	h.Write(d.v[:])
+	sum := h.checkSum()
+	var rc [8]byte
+	binary.BigEndian.PutUint64(rc[:], d.reseedCounter)
+	addMod(d.v[:], sum[:])
+	addMod(d.v[:], d.c[:])
+	addMod(d.v[:], rc[:])
+	d.reseedCounter++
+	return out, nil
+}
+
+// hashDF is the Hash_df derivation function. It fills out with the hash of
+// the concatenation of in.
+func hashDF(out []byte, in ...[]byte) {
+	var (
+		d   digest
+		hdr [5]byte
+	)
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(out)*8))
+	for n := 0; n < len(out); {
+		hdr[0]++
+		d.Reset()
+		d.Write(hdr[:])
+		for _, b := range in {
+			d.Write(b)
+		}
+		sum := d.checkSum()
+		n += copy(out[n:], sum[:])
+	}
+}
+
+// addMod sets v = (v + x) mod 2^(8*len(v)), both big-endian, with x no
+// longer than v.
+func addMod(v, x []byte) {
+	var carry uint
+	i, j := len(v)-1, len(x)-1
+	for ; i >= 0; i, j = i-1, j-1 {
+		s := uint(v[i]) + carry
+		if j >= 0 {
+			s += uint(x[j])
+		} else if carry == 0 {
+			break
+		}
+		v[i] = byte(s)
+		carry = s >> 8
+	}
+}
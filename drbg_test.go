@@ -0,0 +1,105 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func seq(from, to int) []byte {
+	b := make([]byte, 0, to-from)
+	for i := from; i < to; i++ {
+		b = append(b, byte(i))
+	}
+	return b
+}
+
+// Known answers were computed with an independent Hash_DRBG implementation.
+func TestDRBG(t *testing.T) {
+	d, err := NewDRBG(seq(0, 32), seq(32, 48), []byte("personalization"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		n          int
+		additional string
+		reseed     bool
+		want       string
+	}{
+		{64, "", false, "4ea563b95851e9340545b90202f857e476a33a64b56a775e3048bd6c139535a5" +
+			"ef09651533eb1a5569f7bffa32bf9566abc18e85e44ccbd65c2cbdd0ac5e2a03"},
+		{100, "additional", false, "f0f670f3e0f6f5c7983ee17cecccac2feef09496bc7e378ed38dde879c766509" +
+			"032be0da2ee1e6f2710300a5b0254f8fd51cb8d02b4643aef3b87e72ec52b521" +
+			"3263b4a587fcabf103fae0626d4698dc35ad63683bb54e7f28901a0274cedddf94cdb0f1"},
+		{32, "", true, "fa1821f547f7cf0106aeaa5ddd612bca0956a96a260012454d2b3ab3e4dfe9cb"},
+	}
+	for i, s := range steps {
+		if s.reseed {
+			if err := d.Reseed(seq(48, 80), []byte("reseed")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out, err := d.Generate(s.n, []byte(s.additional))
+		if err != nil {
+			t.Fatalf("#%d: Generate: %v", i, err)
+		}
+		if got := hex.EncodeToString(out); got != s.want {
+			t.Fatalf("#%d: Generate \ngot : %s \nwant: %s", i, got, s.want)
+		}
+	}
+}
+
+func TestDRBGDeterministic(t *testing.T) {
+	a, _ := NewDRBG(seq(0, 32), nil, nil)
+	b, _ := NewDRBG(seq(0, 32), nil, nil)
+	for i := 0; i < 5; i++ {
+		x, _ := a.Generate(50, nil)
+		y, _ := b.Generate(50, nil)
+		if !bytes.Equal(x, y) {
+			t.Fatalf("#%d: identical seeds produced %x and %x", i, x, y)
+		}
+	}
+}
+
+func TestDRBGErrors(t *testing.T) {
+	if _, err := NewDRBG(make([]byte, 31), nil, nil); err == nil {
+		t.Fatal("NewDRBG accepted 31 bytes of entropy")
+	}
+	d, _ := NewDRBG(make([]byte, 32), nil, nil)
+	if _, err := d.Generate(drbgMaxRequest+1, nil); err == nil {
+		t.Fatal("Generate accepted an oversized request")
+	}
+	if err := d.Reseed(nil, nil); err == nil {
+		t.Fatal("Reseed accepted empty entropy")
+	}
+
+	d.reseedCounter = drbgReseedInterval
+	if _, err := d.Generate(1, nil); err != nil {
+		t.Fatalf("last Generate before reseed: %v", err)
+	}
+	if _, err := d.Generate(1, nil); err != ErrReseedRequired {
+		t.Fatalf("Generate past reseed interval: got %v, want ErrReseedRequired", err)
+	}
+	d.Reseed(make([]byte, 32), nil)
+	if _, err := d.Generate(1, nil); err != nil {
+		t.Fatalf("Generate after Reseed: %v", err)
+	}
+}
+
+func TestAddMod(t *testing.T) {
+	v := []byte{0x00, 0xff, 0xff}
+	addMod(v, []byte{0x01})
+	if !bytes.Equal(v, []byte{0x01, 0x00, 0x00}) {
+		t.Fatalf("carry: got %x", v)
+	}
+	v = []byte{0xff, 0xff}
+	addMod(v, []byte{0x00, 0x02})
+	if !bytes.Equal(v, []byte{0x00, 0x01}) {
+		t.Fatalf("wrap: got %x", v)
+	}
+}
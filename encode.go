@@ -0,0 +1,149 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// SumHex returns the SM3 checksum of data as 64 lowercase hex digits.
+// Use strings.ToUpper on the result where uppercase is required.
+func SumHex(data []byte) string {
+	sum := Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SumHexInput returns the SM3 checksum of the bytes encoded by hexData,
+// hex digits in either case, as test vectors are usually written. The
+// empty string is the empty message. Malformed hex is an error wrapping
+// the one from encoding/hex; odd-length hex also matches ErrInvalidLength.
+func SumHexInput(hexData string) ([Size]byte, error) {
+	data, err := hex.DecodeString(hexData)
+	if err != nil {
+		return [Size]byte{}, hexInputError{err}
+	}
+	return Sum(data), nil
+}
+
+// hexInputError wraps an error from decoding the input of SumHexInput.
+type hexInputError struct {
+	err error
+}
+
+func (e hexInputError) Error() string {
+	return "sm3: invalid hex input: " + e.err.Error()
+}
+
+func (e hexInputError) Unwrap() error {
+	return e.err
+}
+
+// Is reports odd-length input as ErrInvalidLength.
+func (e hexInputError) Is(target error) bool {
+	return target == ErrInvalidLength && e.err == hex.ErrLength
+}
+
+// HexSum returns the checksum of the data written so far as 64 lowercase
+// hex digits. Like Sum it does not change the underlying hash state.
+func (d *digest) HexSum() string {
+	sum := d.checkSum()
+	return hex.EncodeToString(sum[:])
+}
+
+// SumBase64 returns the SM3 checksum of data in the padded standard base64
+// encoding of RFC 4648, 44 characters long.
+func SumBase64(data []byte) string {
+	sum := Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// SumBase64URL is like SumBase64 but uses the padded URL-safe alphabet.
+func SumBase64URL(data []byte) string {
+	sum := Sum(data)
+	return base64.URLEncoding.EncodeToString(sum[:])
+}
+
+// Base64Sum returns the checksum of the data written so far in the padded
+// standard base64 encoding. Like Sum it does not change the underlying hash
+// state.
+func (d *digest) Base64Sum() string {
+	sum := d.checkSum()
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// WriteSum writes the checksum of the data written so far to w. Like Sum it
+// does not change the underlying hash state.
+func (d *digest) WriteSum(w io.Writer) (int64, error) {
+	sum := d.checkSum()
+	n, err := w.Write(sum[:])
+	return int64(n), err
+}
+
+// A Digest is an SM3 checksum that marshals to and from text, and so JSON,
+// as 64 lowercase hex digits. Being an array it is comparable and can be
+// used as a map key.
+type Digest [Size]byte
+
+// SumTyped returns the SM3 checksum of data as a Digest.
+func SumTyped(data []byte) Digest {
+	return Digest(Sum(data))
+}
+
+// String returns d as 64 lowercase hex digits.
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// Bytes returns a copy of d as a byte slice.
+func (d Digest) Bytes() []byte {
+	return append([]byte(nil), d[:]...)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Digest) MarshalText() ([]byte, error) {
+	b := make([]byte, hex.EncodedLen(Size))
+	hex.Encode(b, d[:])
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. text must be exactly
+// 2*Size hex digits, in either case.
+func (d *Digest) UnmarshalText(text []byte) error {
+	if len(text) != hex.EncodedLen(Size) {
+		return stateError("digest text must be 64 hex digits")
+	}
+	var tmp Digest
+	if _, err := hex.Decode(tmp[:], text); err != nil {
+		return stateError("invalid hex digest: " + err.Error())
+	}
+	*d = tmp
+	return nil
+}
+
+// fingerprintPrefix names the algorithm in a fingerprint.
+const fingerprintPrefix = "sm3:"
+
+// Fingerprint returns the SM3 checksum of data as "sm3:" followed by 64
+// lowercase hex digits.
+func Fingerprint(data []byte) string {
+	return fingerprintPrefix + SumHex(data)
+}
+
+// ParseFingerprint returns the checksum of a fingerprint as produced by
+// Fingerprint. The hex digits may be in either case; the prefix must be
+// exactly "sm3:".
+func ParseFingerprint(s string) ([Size]byte, error) {
+	if !strings.HasPrefix(s, fingerprintPrefix) {
+		return [Size]byte{}, stateError("fingerprint must start with " + fingerprintPrefix)
+	}
+	var d Digest
+	if err := d.UnmarshalText([]byte(s[len(fingerprintPrefix):])); err != nil {
+		return [Size]byte{}, err
+	}
+	return d, nil
+}
@@ -0,0 +1,218 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSumHex(t *testing.T) {
+	for _, g := range golden[:3] {
+		s := SumHex([]byte(g.in))
+		if s != g.out {
+			t.Fatalf("SumHex(%q) \ngot : %s \nwant: %s", g.in, s, g.out)
+		}
+		if len(s) != 2*Size || s != strings.ToLower(s) {
+			t.Fatalf("SumHex(%q) = %q, want %d lowercase hex digits", g.in, s, 2*Size)
+		}
+		raw, err := hex.DecodeString(s)
+		if want := Sum([]byte(g.in)); err != nil || string(raw) != string(want[:]) {
+			t.Fatalf("SumHex(%q) decodes to %x, %v; want %x", g.in, raw, err, want)
+		}
+	}
+}
+
+func TestSumHexInput(t *testing.T) {
+	for _, tt := range []struct{ in, out string }{
+		{"", golden[0].out},
+		{"616263", golden[1].out},
+		{strings.Repeat("61626364", 16), golden[2].out},
+		{strings.Repeat("61626364", 16)[:126] + "64", golden[2].out},
+		{"ac1fb06e4dee27f7", "51f8e3f42d026d7a6876969b00281c9d5f62374936c88b78043b21be851fed3e"},
+		{"AC1FB06E4DEE27F7", "51f8e3f42d026d7a6876969b00281c9d5f62374936c88b78043b21be851fed3e"},
+	} {
+		sum, err := SumHexInput(tt.in)
+		if err != nil {
+			t.Fatalf("SumHexInput(%q): %v", tt.in, err)
+		}
+		if s := hex.EncodeToString(sum[:]); s != tt.out {
+			t.Fatalf("SumHexInput(%q) \ngot : %s \nwant: %s", tt.in, s, tt.out)
+		}
+	}
+	for _, in := range []string{"6", "61626"} {
+		_, err := SumHexInput(in)
+		if !errors.Is(err, ErrInvalidLength) || !errors.Is(err, hex.ErrLength) {
+			t.Fatalf("SumHexInput(%q): got %v, want an error wrapping %v and %v", in, err, ErrInvalidLength, hex.ErrLength)
+		}
+	}
+	for _, in := range []string{"zz", "61 62"} {
+		_, err := SumHexInput(in)
+		var ib hex.InvalidByteError
+		if !errors.As(err, &ib) || errors.Is(err, ErrInvalidLength) {
+			t.Fatalf("SumHexInput(%q): got %v, want an error wrapping a hex.InvalidByteError", in, err)
+		}
+	}
+}
+
+func TestHexSum(t *testing.T) {
+	d := New().(*digest)
+	io.WriteString(d, "ab")
+	d.HexSum()
+	io.WriteString(d, "c")
+	if s := d.HexSum(); s != golden[1].out {
+		t.Fatalf("HexSum \ngot : %s \nwant: %s", s, golden[1].out)
+	}
+}
+
+func TestSumBase64(t *testing.T) {
+	for _, g := range golden[:3] {
+		want := Sum([]byte(g.in))
+		for _, tt := range []struct {
+			name string
+			s    string
+			enc  *base64.Encoding
+		}{
+			{"SumBase64", SumBase64([]byte(g.in)), base64.StdEncoding},
+			{"SumBase64URL", SumBase64URL([]byte(g.in)), base64.URLEncoding},
+		} {
+			if len(tt.s) != 44 {
+				t.Fatalf("%s(%q) = %q, want 44 characters", tt.name, g.in, tt.s)
+			}
+			raw, err := tt.enc.DecodeString(tt.s)
+			if err != nil || !bytes.Equal(raw, want[:]) {
+				t.Fatalf("%s(%q) decodes to %x, %v; want %x", tt.name, g.in, raw, err, want)
+			}
+		}
+	}
+}
+
+func TestBase64Sum(t *testing.T) {
+	d := New().(*digest)
+	io.WriteString(d, "ab")
+	d.Base64Sum()
+	io.WriteString(d, "c")
+	if s, want := d.Base64Sum(), SumBase64([]byte("abc")); s != want {
+		t.Fatalf("Base64Sum \ngot : %s \nwant: %s", s, want)
+	}
+}
+
+func TestWriteSum(t *testing.T) {
+	d := New().(*digest)
+	io.WriteString(d, "ab")
+	var buf bytes.Buffer
+	if n, err := d.WriteSum(&buf); n != Size || err != nil {
+		t.Fatalf("WriteSum = %d, %v; want %d, nil", n, err, Size)
+	}
+	if want := Sum([]byte("ab")); !bytes.Equal(buf.Bytes(), want[:]) {
+		t.Fatalf("WriteSum wrote %x, want %x", buf.Bytes(), want)
+	}
+
+	io.WriteString(d, "c")
+	buf.Reset()
+	d.WriteSum(&buf)
+	if s := hex.EncodeToString(buf.Bytes()); s != golden[1].out {
+		t.Fatalf("WriteSum after more data \ngot : %s \nwant: %s", s, golden[1].out)
+	}
+}
+
+func TestDigestJSON(t *testing.T) {
+	type record struct {
+		Name string
+		Sum  Digest
+	}
+	in := record{"abc", SumTyped([]byte("abc"))}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if want := `{"Name":"abc","Sum":"` + golden[1].out + `"}`; string(b) != want {
+		t.Fatalf("json.Marshal \ngot : %s \nwant: %s", b, want)
+	}
+	var out record
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if out != in {
+		t.Fatalf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestDigestUnmarshalTextInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		golden[1].out[:62],
+		golden[1].out + "00",
+		"zz" + golden[1].out[2:],
+		" " + golden[1].out[1:],
+	} {
+		var d Digest
+		if err := d.UnmarshalText([]byte(s)); err == nil {
+			t.Fatalf("UnmarshalText(%q) succeeded", s)
+		}
+		if d != (Digest{}) {
+			t.Fatalf("UnmarshalText(%q) modified the digest", s)
+		}
+	}
+	var d Digest
+	if err := d.UnmarshalText([]byte(strings.ToUpper(golden[1].out))); err != nil {
+		t.Fatalf("UnmarshalText of uppercase hex: %v", err)
+	}
+}
+
+func TestDigestMapKey(t *testing.T) {
+	seen := make(map[Digest]int)
+	for _, s := range []string{"a", "b", "a", "c", "b", "a"} {
+		seen[SumTyped([]byte(s))]++
+	}
+	if len(seen) != 3 || seen[SumTyped([]byte("a"))] != 3 {
+		t.Fatalf("dedup table = %v", seen)
+	}
+
+	d := SumTyped([]byte("abc"))
+	if s := d.String(); s != hex.EncodeToString(d[:]) || s != golden[1].out {
+		t.Fatalf("String \ngot : %s \nwant: %s", s, golden[1].out)
+	}
+	b := d.Bytes()
+	if !bytes.Equal(b, d[:]) {
+		t.Fatalf("Bytes = %x, want %x", b, d)
+	}
+	b[0] ^= 1
+	if d != SumTyped([]byte("abc")) {
+		t.Fatal("modifying the result of Bytes changed the digest")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	fp := Fingerprint([]byte("abc"))
+	if want := "sm3:" + golden[1].out; fp != want {
+		t.Fatalf("\ngot : %s \nwant: %s", fp, want)
+	}
+	sum, err := ParseFingerprint(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := Sum([]byte("abc")); sum != want {
+		t.Fatalf("ParseFingerprint = %x, want %x", sum, want)
+	}
+	for _, s := range []string{
+		golden[1].out,
+		"sha256:" + golden[1].out,
+		"SM3:" + golden[1].out,
+		"sm3:" + golden[1].out[:63],
+		"sm3:" + strings.Repeat("g", 64),
+		"sm3:",
+	} {
+		if _, err := ParseFingerprint(s); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("ParseFingerprint(%q): got %v, want an error wrapping %v", s, err, ErrInvalidState)
+		}
+	}
+}
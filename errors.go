@@ -0,0 +1,38 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned, possibly wrapped with more detail, by the fallible APIs
+// of this package. Use errors.Is to test for them.
+var (
+	// ErrInvalidState reports a marshaled or resumed hash state, or a
+	// digest in text form, that is malformed or inconsistent.
+	ErrInvalidState = errors.New("sm3: invalid hash state")
+
+	// ErrInputTooLong is the value panicked with when a checksum is
+	// requested after more than 2^61-1 bytes, i.e. 2^64-1 bits, have been
+	// written, the most SM3 is defined for. Hashes from NewLimited return
+	// it from writes past their limit.
+	ErrInputTooLong = errors.New("sm3: input longer than 2^64-1 bits")
+
+	// ErrInvalidLength reports a length, size or count argument that is
+	// out of range. The KDFs, NewHMACCT and NewSegmentHasher panic with it.
+	ErrInvalidLength = errors.New("sm3: invalid length")
+)
+
+// stateError returns an error wrapping ErrInvalidState with detail.
+func stateError(detail string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidState, detail)
+}
+
+// lengthError returns an error wrapping ErrInvalidLength with detail.
+func lengthError(detail string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidLength, detail)
+}
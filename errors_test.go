@@ -0,0 +1,128 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"testing"
+)
+
+func TestErrInvalidState(t *testing.T) {
+	state, _ := New().(encoding.BinaryMarshaler).MarshalBinary()
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{"UnmarshalBinary", New().(encoding.BinaryUnmarshaler).UnmarshalBinary(state[:len(state)-1])},
+		{"GobDecode", new(digest).GobDecode(nil)},
+		{"NewWithState", func() error {
+			_, err := NewWithState(iv, make([]byte, 3), 4)
+			return err
+		}()},
+		{"Digest.UnmarshalText", new(Digest).UnmarshalText([]byte("00"))},
+		{"Digest.UnmarshalText hex", new(Digest).UnmarshalText(bytes.Repeat([]byte("z"), 2*Size))},
+	} {
+		if !errors.Is(tt.err, ErrInvalidState) {
+			t.Fatalf("%s: got %v, want an error wrapping %v", tt.name, tt.err, ErrInvalidState)
+		}
+	}
+}
+
+func TestErrInvalidLength(t *testing.T) {
+	drbg, _ := NewDRBG(make([]byte, 32), nil, nil)
+	hmacDRBG, _ := NewHMACDRBG(make([]byte, 32), nil, nil)
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{"SumTruncated", func() error {
+			_, err := SumTruncated(nil, Size+1)
+			return err
+		}()},
+		{"TreeSum", func() error {
+			_, err := TreeSum(bytes.NewReader(nil), 0, 0)
+			return err
+		}()},
+		{"DRBG.Generate", func() error {
+			_, err := drbg.Generate(-1, nil)
+			return err
+		}()},
+		{"HMACDRBG.Generate", func() error {
+			_, err := hmacDRBG.Generate(-1, nil)
+			return err
+		}()},
+		{"NewDRBG", func() error {
+			_, err := NewDRBG(make([]byte, 31), nil, nil)
+			return err
+		}()},
+		{"DRBG.Reseed", drbg.Reseed(nil, nil)},
+		{"NewHMACDRBG", func() error {
+			_, err := NewHMACDRBG(make([]byte, 31), nil, nil)
+			return err
+		}()},
+		{"HMACDRBG.Reseed", hmacDRBG.Reseed(nil, nil)},
+		{"MerkleProof", func() error {
+			_, err := MerkleProof(nil, 0)
+			return err
+		}()},
+		{"MerkleProof index", func() error {
+			_, err := MerkleProof([][]byte{nil}, 1)
+			return err
+		}()},
+		{"ComputeZA", func() error {
+			_, err := ComputeZA(nil, nil, nil, nil, nil, nil, nil)
+			return err
+		}()},
+	} {
+		if !errors.Is(tt.err, ErrInvalidLength) {
+			t.Fatalf("%s: got %v, want an error wrapping %v", tt.name, tt.err, ErrInvalidLength)
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		f    func()
+	}{
+		{"KDF", func() { KDF(nil, -1) }},
+		{"MGF1", func() { MGF1(nil, -1) }},
+		{"PBKDF2", func() { PBKDF2(nil, nil, 1, 0) }},
+		{"PBKDF2 iterations", func() { PBKDF2(nil, nil, 0, Size) }},
+		{"NewHMACCT", func() { NewHMACCT(make([]byte, HMACCTMaxKeyLen+1)) }},
+		{"NewSegmentHasher", func() { NewSegmentHasher(0) }},
+		{"HKDFExpand", func() { HKDFExpand(nil, nil, 255*Size+1) }},
+		{"PRF", func() { PRF(nil, nil, nil, -1) }},
+	} {
+		func() {
+			defer func() {
+				err, _ := recover().(error)
+				if !errors.Is(err, ErrInvalidLength) {
+					t.Fatalf("%s: panicked with %v, want an error wrapping %v", tt.name, err, ErrInvalidLength)
+				}
+			}()
+			tt.f()
+		}()
+	}
+}
+
+func TestErrInputTooLong(t *testing.T) {
+	d := New().(*digest)
+	d.len = maxLen + 1
+	defer func() {
+		err, _ := recover().(error)
+		if !errors.Is(err, ErrInputTooLong) {
+			t.Fatalf("Sum panicked with %v, want %v", err, ErrInputTooLong)
+		}
+	}()
+	d.Sum(nil)
+}
+
+func TestErrorMessages(t *testing.T) {
+	_, err := SumTruncated(nil, 0)
+	if got, want := err.Error(), "sm3: invalid length: truncated length out of range"; got != want {
+		t.Fatalf("\ngot : %s \nwant: %s", got, want)
+	}
+}
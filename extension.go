@@ -0,0 +1,33 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "encoding/binary"
+
+// Padding returns the padding SM3 appends to a message of n bytes before
+// the final compression: a 0x80 byte, zeros up to 56 mod 64 bytes, and the
+// bit length as a 64-bit big-endian integer.
+func Padding(n uint64) []byte {
+	pad := make([]byte, 1, chunk+8)
+	pad[0] = 0x80
+	pad = append(pad, make([]byte, (chunk+55-n%chunk)%chunk)...)
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], n<<3)
+	return append(pad, l[:]...)
+}
+
+// LengthExtension demonstrates why SM3(secret || message) is not a MAC.
+// Given only sum = SM3(x) and n = len(x), it returns
+// SM3(x || Padding(n) || ext) without knowing x. Use NewHMAC for MACs; this
+// function exists to test that other systems are not vulnerable.
+func LengthExtension(sum [Size]byte, n uint64, ext []byte) [Size]byte {
+	var d digest
+	for i := range d.h {
+		d.h[i] = binary.BigEndian.Uint32(sum[4*i:])
+	}
+	d.len = n + uint64(len(Padding(n)))
+	d.Write(ext)
+	return d.checkSum()
+}
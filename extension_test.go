@@ -0,0 +1,48 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPadding(t *testing.T) {
+	for _, n := range []uint64{0, 1, 55, 56, 63, 64, 1000} {
+		p := Padding(n)
+		if (n+uint64(len(p)))%chunk != 0 || len(p) < 9 || len(p) > chunk+8 {
+			t.Fatalf("Padding(%d) has length %d", n, len(p))
+		}
+		if p[0] != 0x80 {
+			t.Fatalf("Padding(%d) starts with %#x", n, p[0])
+		}
+	}
+}
+
+func TestLengthExtension(t *testing.T) {
+	secret := []byte("server secret")
+	msg := []byte("user=alice&role=user")
+	ext := []byte("&role=admin")
+
+	x := bytes.Join([][]byte{secret, msg}, nil)
+	naive := Sum(x)
+	forged := LengthExtension(naive, uint64(len(x)), ext)
+
+	var forgedMsg []byte
+	forgedMsg = append(forgedMsg, x...)
+	forgedMsg = append(forgedMsg, Padding(uint64(len(x)))...)
+	forgedMsg = append(forgedMsg, ext...)
+	if want := Sum(forgedMsg); forged != want {
+		t.Fatalf("forged %x, want %x", forged, want)
+	}
+
+}
+
+func TestEnvelopeMAC(t *testing.T) {
+	key, msg := []byte("key"), []byte("message")
+	if got, want := EnvelopeMAC(key, msg), Sum([]byte("keymessagekey")); got != want {
+		t.Fatalf("EnvelopeMAC = %x, want %x", got, want)
+	}
+}
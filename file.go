@@ -0,0 +1,38 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"io"
+	"os"
+)
+
+// SumFile returns the SM3 checksum of the contents of the named file. The
+// file is streamed through the hash rather than read into memory. Errors
+// opening and reading the file are returned as the *os.PathError reported
+// by the os package, with Op "open" and "read" respectively.
+func SumFile(path string) ([Size]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	defer f.Close()
+
+	var d digest
+	d.Reset()
+	buf := getReadBuf()
+	defer putReadBuf(buf)
+	for {
+		n, err := f.Read(buf[:])
+		d.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return [Size]byte{}, err
+		}
+	}
+	return d.checkSum(), nil
+}
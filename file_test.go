@@ -0,0 +1,56 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempDir creates a scratch directory that the caller removes.
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "sm3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSumFile(t *testing.T) {
+	data := make([]byte, 3<<20+17)
+	rand.New(rand.NewSource(1)).Read(data)
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := SumFile(path)
+	if err != nil {
+		t.Fatalf("SumFile: %v", err)
+	}
+	if want := Sum(data); sum != want {
+		t.Fatalf("SumFile = %x, want %x", sum, want)
+	}
+}
+
+func TestSumFileErrors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, err := SumFile(filepath.Join(dir, "missing"))
+	if pe, ok := err.(*os.PathError); !ok || pe.Op != "open" {
+		t.Fatalf("missing file: got %v, want open error", err)
+	}
+
+	_, err = SumFile(dir)
+	if pe, ok := err.(*os.PathError); !ok || pe.Op != "read" {
+		t.Fatalf("directory: got %v, want read error", err)
+	}
+}
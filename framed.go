@@ -0,0 +1,36 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "encoding/binary"
+
+// A FramedHasher computes the SM3 checksum of a sequence of fields, each
+// preceded by its length as an 8-byte big-endian integer, so that fields
+// with the same concatenation but different boundaries, such as ("ab",
+// "c") and ("a", "bc"), hash differently.
+type FramedHasher struct {
+	d digest
+}
+
+// NewFramedHasher returns a FramedHasher with no fields written.
+func NewFramedHasher() *FramedHasher {
+	f := new(FramedHasher)
+	f.d.Reset()
+	return f
+}
+
+// WriteField adds field as the next field.
+func (f *FramedHasher) WriteField(field []byte) {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(field)))
+	f.d.Write(n[:])
+	f.d.Write(field)
+}
+
+// Sum returns the checksum of the fields written so far. It does not
+// change the underlying state, so more fields may follow.
+func (f *FramedHasher) Sum() [Size]byte {
+	return f.d.checkSum()
+}
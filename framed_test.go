@@ -0,0 +1,41 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "testing"
+
+func framedSum(fields ...string) [Size]byte {
+	f := NewFramedHasher()
+	for _, s := range fields {
+		f.WriteField([]byte(s))
+	}
+	return f.Sum()
+}
+
+func TestFramedHasher(t *testing.T) {
+	// Two fields "ab" and "c" are 0000000000000002 6162 0000000000000001 63.
+	want := Sum([]byte("\x00\x00\x00\x00\x00\x00\x00\x02ab\x00\x00\x00\x00\x00\x00\x00\x01c"))
+	if got := framedSum("ab", "c"); got != want {
+		t.Fatalf("\ngot : %x \nwant: %x", got, want)
+	}
+
+	for _, g := range [][2][]string{
+		{{"ab", "c"}, {"a", "bc"}},
+		{{"abc"}, {"abc", ""}},
+		{{""}, {}},
+	} {
+		if framedSum(g[0]...) == framedSum(g[1]...) {
+			t.Fatalf("fields %q and %q have the same framed checksum", g[0], g[1])
+		}
+	}
+
+	f := NewFramedHasher()
+	f.WriteField([]byte("ab"))
+	f.Sum()
+	f.WriteField([]byte("c"))
+	if f.Sum() != want {
+		t.Fatal("Sum changed the state")
+	}
+}
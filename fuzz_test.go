@@ -0,0 +1,33 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package sm3
+
+import (
+	"bytes"
+	"testing"
+)
+
+func FuzzSM3(f *testing.F) {
+	for _, g := range golden {
+		f.Add(goldenInput(g))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		want := SumReference(data)
+		if got := Sum(data); got != want {
+			t.Fatalf("Sum = %x, SumReference = %x", got, want)
+		}
+
+		// Split writes exercise the buffering of partial blocks.
+		h := New()
+		h.Write(data[:len(data)/3])
+		h.Write(data[len(data)/3:])
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("New().Sum = %x, SumReference = %x", got, want)
+		}
+	})
+}
@@ -0,0 +1,241 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// NewHMAC returns a new hash.Hash computing HMAC-SM3 (RFC 2104) with the
+// given key. Keys longer than BlockSize are hashed first, shorter keys are
+// zero padded.
+func NewHMAC(key []byte) hash.Hash {
+	h := new(hmacDigest)
+	h.key.init(key)
+	h.Reset()
+	return h
+}
+
+// hmacDigest is the hash.Hash returned by NewHMAC.
+type hmacDigest struct {
+	key   HMACKey
+	inner digest // key.inner after absorbing the message so far
+}
+
+func (h *hmacDigest) Write(p []byte) (int, error) {
+	return h.inner.Write(p)
+}
+
+func (h *hmacDigest) Size() int {
+	return Size
+}
+
+func (h *hmacDigest) BlockSize() int {
+	return BlockSize
+}
+
+// Sum appends the MAC of the data written so far to b. It does not change
+// the underlying state.
+func (h *hmacDigest) Sum(b []byte) []byte {
+	sum := h.key.finish(&h.inner)
+	return append(b, sum[:]...)
+}
+
+// Reset returns h to its keyed state before any data was written.
+func (h *hmacDigest) Reset() {
+	h.inner = h.key.inner
+}
+
+// The marshaled state of an HMAC is hmacMagic followed by the marshaled
+// digest states of the inner and outer padded key and of the inner hash
+// of the message so far, in that order. The first two are derived from the
+// key, so the state must be protected like the key itself.
+const (
+	hmacMagic         = "sm3hmac\x01"
+	hmacMarshaledSize = len(hmacMagic) + 3*marshaledSize
+)
+
+func (h *hmacDigest) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, hmacMarshaledSize)
+	b = append(b, hmacMagic...)
+	b, _ = h.key.inner.AppendBinary(b)
+	b, _ = h.key.outer.AppendBinary(b)
+	return h.inner.AppendBinary(b)
+}
+
+func (h *hmacDigest) UnmarshalBinary(b []byte) error {
+	if len(b) < len(hmacMagic) || string(b[:len(hmacMagic)]) != hmacMagic {
+		return stateError("bad HMAC identifier")
+	}
+	if len(b) != hmacMarshaledSize {
+		return stateError("bad HMAC size")
+	}
+	b = b[len(hmacMagic):]
+	var k HMACKey
+	var inner digest
+	for _, d := range []*digest{&k.inner, &k.outer, &inner} {
+		if err := d.UnmarshalBinary(b[:marshaledSize]); err != nil {
+			return err
+		}
+		b = b[marshaledSize:]
+	}
+	if k.inner.len != BlockSize || k.outer.len != BlockSize || inner.len < BlockSize {
+		return stateError("bad HMAC key state")
+	}
+	h.key, h.inner = k, inner
+	return nil
+}
+
+// NewKeyedMAC returns a new hash.Hash computing HMAC-SM3 under the key
+// HMAC-SM3(key, customization). Protocols sharing a key but using distinct
+// customization strings thus get unrelated MACs.
+func NewKeyedMAC(key, customization []byte) hash.Hash {
+	k := SumHMAC(key, customization)
+	return NewHMAC(k[:])
+}
+
+// NewHMACStretched returns a new hash.Hash computing HMAC-SM3 under the
+// Size byte key PBKDF2(password, salt, iter, Size), for MACs keyed by
+// passwords or other low-entropy secrets. It panics if iter is not
+// positive.
+func NewHMACStretched(password, salt []byte, iter int) hash.Hash {
+	return NewHMAC(PBKDF2(password, salt, iter, Size))
+}
+
+// EnvelopeMAC returns SM3(key || message || key). Unlike SM3(key ||
+// message) it cannot be length-extended, see LengthExtension, but it has
+// no security proof comparable to HMAC's; prefer SumHMAC, and use this only
+// where an envelope MAC is mandated.
+func EnvelopeMAC(key, message []byte) [Size]byte {
+	var d digest
+	d.Reset()
+	d.Write(key)
+	d.Write(message)
+	d.Write(key)
+	return d.checkSum()
+}
+
+// SumHMAC returns the HMAC-SM3 of data under key.
+func SumHMAC(key, data []byte) [Size]byte {
+	var k HMACKey
+	k.init(key)
+	return k.Sum(data)
+}
+
+// SumHMACInto computes the HMAC-SM3 of data under key directly into dst.
+// Like SumHMAC it keeps all state on the stack and does not allocate.
+func SumHMACInto(dst *[Size]byte, key, data []byte) {
+	var k HMACKey
+	k.init(key)
+	*dst = k.Sum(data)
+}
+
+// AuthTag returns the HMAC-SM3 under key of
+//
+//	len(ad) || ad || len(message) || message
+//
+// with the lengths as 8-byte big-endian integers, so that no bytes can be
+// moved between the associated data ad and message without changing the
+// tag.
+func AuthTag(key, ad, message []byte) [Size]byte {
+	var k HMACKey
+	k.init(key)
+	d := k.inner
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(ad)))
+	d.Write(n[:])
+	d.Write(ad)
+	binary.BigEndian.PutUint64(n[:], uint64(len(message)))
+	d.Write(n[:])
+	d.Write(message)
+	return k.finish(&d)
+}
+
+// RecordMAC returns the record MAC of TLCP (GM/T 0024) and TLS 1.2,
+// HMAC-SM3 under key of
+//
+//	seq_num || type || version || length || fragment
+//
+// where seq_num is 8 bytes, type 1 byte, and version and the fragment
+// length 2 bytes each, all big-endian. It panics if fragment is longer
+// than 65535 bytes.
+func RecordMAC(key []byte, seqNum uint64, recordType byte, version uint16, fragment []byte) [Size]byte {
+	if len(fragment) > 0xffff {
+		panic(lengthError("record fragment longer than 65535 bytes"))
+	}
+	var hdr [13]byte
+	binary.BigEndian.PutUint64(hdr[:8], seqNum)
+	hdr[8] = recordType
+	binary.BigEndian.PutUint16(hdr[9:11], version)
+	binary.BigEndian.PutUint16(hdr[11:], uint16(len(fragment)))
+
+	var k HMACKey
+	k.init(key)
+	d := k.inner
+	d.Write(hdr[:])
+	d.Write(fragment)
+	return k.finish(&d)
+}
+
+// Short64 returns the leading 8 bytes of HMAC-SM3(key, data) as a
+// big-endian uint64, a keyed short hash for hash tables exposed to
+// untrusted keys. It costs at least four SM3 compressions per call, several
+// times the work of SipHash, so prefer hash/maphash where an algorithm
+// outside SM3 is acceptable.
+func Short64(key [16]byte, data []byte) uint64 {
+	var k HMACKey
+	k.init(key[:])
+	sum := k.Sum(data)
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// An HMACKey holds the SM3 states after absorbing the inner and outer
+// padded key, so that any number of MACs under one key start from copies of
+// them instead of re-keying. It is safe for concurrent use.
+type HMACKey struct {
+	inner, outer digest
+}
+
+// NewHMACKey returns the precomputed HMAC-SM3 key schedule for key.
+func NewHMACKey(key []byte) *HMACKey {
+	k := new(HMACKey)
+	k.init(key)
+	return k
+}
+
+func (k *HMACKey) init(key []byte) {
+	if len(key) > BlockSize {
+		sum := Sum(key)
+		key = sum[:]
+	}
+	var pad [BlockSize]byte
+	copy(pad[:], key)
+	for i := range pad {
+		pad[i] ^= 0x36
+	}
+	k.inner.Reset()
+	k.inner.Write(pad[:])
+	for i := range pad {
+		pad[i] ^= 0x36 ^ 0x5c
+	}
+	k.outer.Reset()
+	k.outer.Write(pad[:])
+}
+
+// finish completes the MAC whose inner hash has absorbed its message.
+func (k *HMACKey) finish(inner *digest) [Size]byte {
+	sum := inner.checkSum()
+	d := k.outer
+	d.Write(sum[:])
+	return d.checkSum()
+}
+
+// Sum returns the HMAC-SM3 of data under k.
+func (k *HMACKey) Sum(data []byte) [Size]byte {
+	d := k.inner
+	d.Write(data)
+	return k.finish(&d)
+}
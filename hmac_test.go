@@ -0,0 +1,345 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+type hmacTest struct {
+	key  string
+	data string
+	out  string
+}
+
+// Inputs follow RFC 4231, outputs were computed with an independent
+// HMAC-SM3 implementation.
+var hmacGolden = []hmacTest{
+	{
+		strings.Repeat("0b", 20),
+		hex.EncodeToString([]byte("Hi There")),
+		"51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70",
+	},
+	{
+		hex.EncodeToString([]byte("Jefe")),
+		hex.EncodeToString([]byte("what do ya want for nothing?")),
+		"2e87f1d16862e6d964b50a5200bf2b10b764faa9680a296a2405f24bec39f882",
+	},
+	{
+		strings.Repeat("aa", 20),
+		strings.Repeat("dd", 50),
+		"dd9421e1c725bdf52ec1aa34edadb3c97f5951a83a2fa93f73a7902bc1dcc777",
+	},
+	{
+		"0102030405060708090a0b0c0d0e0f10111213141516171819",
+		strings.Repeat("cd", 50),
+		"b57c79be03472aeb8cada581dea332cb2ba83d19cb1b052dd07194def75fb8cd",
+	},
+	{
+		strings.Repeat("aa", 131),
+		hex.EncodeToString([]byte("Test Using Larger Than Block-Size Key - Hash Key First")),
+		"b4fd844e13342002f0b2e0690ea7741f1497d993a70494cea601e657bedf67a0",
+	},
+	{
+		strings.Repeat("aa", 131),
+		hex.EncodeToString([]byte("This is a test using a larger than block-size key and a larger than block-size data. The key needs to be hashed before being used by the HMAC algorithm.")),
+		"5acbdeb0c8c1ef3a99088fe51c0a1d5f4e1c175935f016aee74eb8056db18acb",
+	},
+	{
+		strings.Repeat("01", BlockSize),
+		hex.EncodeToString([]byte("exact block size key")),
+		"e4d4842e088638c0a7013240787d6aa5a835de44bda3f21129a319902d347510",
+	},
+	// empty key and empty message
+	{"", "", "0d23f72ba15e9c189a879aefc70996b06091de6e64d31b7a84004356dd915261"},
+}
+
+func TestHMAC(t *testing.T) {
+	for i, g := range hmacGolden {
+		key, _ := hex.DecodeString(g.key)
+		data, _ := hex.DecodeString(g.data)
+
+		h := NewHMAC(key)
+		if h.Size() != Size || h.BlockSize() != BlockSize {
+			t.Fatalf("#%d: Size/BlockSize = %d/%d", i, h.Size(), h.BlockSize())
+		}
+		for j := 0; j < 2; j++ {
+			h.Write(data)
+			if s := fmt.Sprintf("%x", h.Sum(nil)); s != g.out {
+				t.Fatalf("#%d[%d]: NewHMAC \ngot : %s \nwant: %s", i, j, s, g.out)
+			}
+			h.Reset()
+		}
+
+		if s := fmt.Sprintf("%x", SumHMAC(key, data)); s != g.out {
+			t.Fatalf("#%d: SumHMAC \ngot : %s \nwant: %s", i, s, g.out)
+		}
+
+		k := NewHMACKey(key)
+		for j := 0; j < 2; j++ {
+			if s := fmt.Sprintf("%x", k.Sum(data)); s != g.out {
+				t.Fatalf("#%d[%d]: HMACKey.Sum \ngot : %s \nwant: %s", i, j, s, g.out)
+			}
+		}
+	}
+}
+
+func TestHMACReset(t *testing.T) {
+	key := []byte("key")
+	fresh := func(data string) []byte {
+		h := NewHMAC(key)
+		io.WriteString(h, data)
+		return h.Sum(nil)
+	}
+	h := NewHMAC(key)
+	io.WriteString(h, "first message")
+	if got, want := h.Sum(nil), fresh("first message"); !bytes.Equal(got, want) {
+		t.Fatalf("first MAC \ngot : %x \nwant: %x", got, want)
+	}
+	h.Reset()
+	io.WriteString(h, "second message")
+	if got, want := h.Sum(nil), fresh("second message"); !bytes.Equal(got, want) {
+		t.Fatalf("MAC after Reset \ngot : %x \nwant: %x", got, want)
+	}
+}
+
+func TestHMACMarshal(t *testing.T) {
+	key := []byte("stream key")
+	msg := []byte(strings.Repeat("authenticated stream ", 10))
+	want := SumHMAC(key, msg)
+	for _, split := range []int{0, 1, 63, 64, 100, len(msg)} {
+		h := NewHMAC(key)
+		h.Write(msg[:split])
+		state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h2 := NewHMAC([]byte("some other key"))
+		if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			t.Fatalf("split %d: %v", split, err)
+		}
+		h2.Write(msg[split:])
+		if got := h2.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("split %d \ngot : %x \nwant: %x", split, got, want)
+		}
+		h2.Reset()
+		h2.Write(msg)
+		if got := h2.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("split %d: Reset after resuming lost the key", split)
+		}
+	}
+
+	state, _ := NewHMAC(key).(encoding.BinaryMarshaler).MarshalBinary()
+	badVersion := append([]byte(nil), state...)
+	badVersion[len(hmacMagic)-1]++
+	plain, _ := New().(encoding.BinaryMarshaler).MarshalBinary()
+	unkeyed := append([]byte(hmacMagic), plain...)
+	unkeyed = append(append(unkeyed, plain...), plain...)
+	for name, b := range map[string][]byte{
+		"empty":   nil,
+		"version": badVersion,
+		"short":   state[:len(state)-1],
+		"plain":   plain,
+		"unkeyed": unkeyed,
+	} {
+		err := NewHMAC(key).(encoding.BinaryUnmarshaler).UnmarshalBinary(b)
+		if !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("%s: got %v, want %v", name, err, ErrInvalidState)
+		}
+	}
+}
+
+func TestSumHMACInto(t *testing.T) {
+	for i, g := range hmacGolden {
+		key, _ := hex.DecodeString(g.key)
+		data, _ := hex.DecodeString(g.data)
+		var dst [Size]byte
+		SumHMACInto(&dst, key, data)
+		if s := fmt.Sprintf("%x", dst); s != g.out {
+			t.Fatalf("#%d: SumHMACInto \ngot : %s \nwant: %s", i, s, g.out)
+		}
+	}
+	var dst [Size]byte
+	if n := testing.AllocsPerRun(10, func() { SumHMACInto(&dst, hmacBenchKey, buf[:100]) }); n > 0 {
+		t.Fatalf("SumHMACInto allocates %v times, want 0", n)
+	}
+}
+
+func TestNewKeyedMAC(t *testing.T) {
+	key := []byte("shared key")
+	msg := []byte("message")
+	tag := func(custom string) []byte {
+		h := NewKeyedMAC(key, []byte(custom))
+		h.Write(msg)
+		return h.Sum(nil)
+	}
+	a, b := tag("protocol A"), tag("protocol B")
+	if bytes.Equal(a, b) {
+		t.Fatalf("different customizations give the same tag %x", a)
+	}
+	if !bytes.Equal(a, tag("protocol A")) {
+		t.Fatal("NewKeyedMAC is not deterministic")
+	}
+	k := SumHMAC(key, []byte("protocol A"))
+	if want := SumHMAC(k[:], msg); !bytes.Equal(a, want[:]) {
+		t.Fatalf("got %x, want %x", a, want)
+	}
+	if plain := SumHMAC(key, msg); bytes.Equal(tag(""), plain[:]) {
+		t.Fatal("empty customization gives the plain HMAC")
+	}
+}
+
+func TestNewHMACStretched(t *testing.T) {
+	password, salt, msg := []byte("password"), []byte("salt"), []byte("message")
+	h := NewHMACStretched(password, salt, 100)
+	h.Write(msg)
+	got := h.Sum(nil)
+	want := SumHMAC(PBKDF2(password, salt, 100, Size), msg)
+	if !bytes.Equal(got, want[:]) {
+		t.Fatalf("\ngot : %x \nwant: %x", got, want)
+	}
+	h = NewHMACStretched(password, salt, 101)
+	h.Write(msg)
+	if bytes.Equal(h.Sum(nil), got) {
+		t.Fatal("iteration count does not change the MAC")
+	}
+}
+
+func TestNewHMACCT(t *testing.T) {
+	msg := []byte("message")
+	for n := 0; n <= HMACCTMaxKeyLen; n++ {
+		key := testData(n)
+		h := NewHMACCT(key)
+		h.Write(msg)
+		if got, want := h.Sum(nil), SumHMAC(key, msg); !bytes.Equal(got, want[:]) {
+			t.Fatalf("key length %d: got %x, want %x", n, got, want)
+		}
+
+		// The state, and so Reset and marshaling, match NewHMAC's.
+		ref := NewHMAC(key)
+		ref.Write(msg)
+		got, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := ref.(encoding.BinaryMarshaler).MarshalBinary()
+		if !bytes.Equal(got, want) {
+			t.Fatalf("key length %d: marshaled state differs from NewHMAC", n)
+		}
+		h.Reset()
+		ref.Reset()
+		if !bytes.Equal(h.Sum(nil), ref.Sum(nil)) {
+			t.Fatalf("key length %d: MAC after Reset differs from NewHMAC", n)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewHMACCT accepted a key longer than HMACCTMaxKeyLen")
+		}
+	}()
+	NewHMACCT(make([]byte, HMACCTMaxKeyLen+1))
+}
+
+func TestAuthTag(t *testing.T) {
+	key := []byte("key")
+	want := "a740b22adf0925222ed191c4bf306ba89ccbca3da7f91f18a5dd32ee22df9064"
+	if s := fmt.Sprintf("%x", AuthTag(key, []byte("header"), []byte("payload"))); s != want {
+		t.Fatalf("\ngot : %s \nwant: %s", s, want)
+	}
+
+	a := AuthTag(key, []byte("ab"), []byte("cd"))
+	b := AuthTag(key, []byte("abc"), []byte("d"))
+	if a == b {
+		t.Fatal("moving a byte from ad to message does not change the tag")
+	}
+	if SumHMAC(key, []byte("ab"+"cd")) != SumHMAC(key, []byte("abc"+"d")) {
+		t.Fatal("naive concatenation unexpectedly distinguishes the splits")
+	}
+}
+
+func TestRecordMAC(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	frag := []byte("hello, TLCP")
+	want := "83ed7f47115f812ceab2355c3ef534a01238cc862a8a0cf276c816c7c0d7aac4"
+	if s := fmt.Sprintf("%x", RecordMAC(key, 1, 23, 0x0101, frag)); s != want {
+		t.Fatalf("\ngot : %s \nwant: %s", s, want)
+	}
+	hdr, _ := hex.DecodeString("0000000000000001170101000b")
+	if got := SumHMAC(key, append(hdr, frag...)); fmt.Sprintf("%x", got) != want {
+		t.Fatalf("header layout \ngot : %x \nwant: %s", got, want)
+	}
+	if RecordMAC(key, 2, 23, 0x0101, frag) == RecordMAC(key, 1, 23, 0x0101, frag) {
+		t.Fatal("sequence number does not change the MAC")
+	}
+}
+
+func TestShort64(t *testing.T) {
+	var seq [16]byte
+	for i := range seq {
+		seq[i] = byte(i)
+	}
+	for _, tt := range []struct {
+		key  [16]byte
+		data string
+		out  uint64
+	}{
+		{[16]byte{}, "", 0x0d23f72ba15e9c18},
+		{seq, "abc", 0x83fd35b3ff621142},
+		{seq, "The quick brown fox jumps over the lazy dog", 0xf74725213c3fa6e0},
+	} {
+		if got := Short64(tt.key, []byte(tt.data)); got != tt.out {
+			t.Fatalf("Short64(%x, %q) = %#016x, want %#016x", tt.key, tt.data, got, tt.out)
+		}
+	}
+	if Short64([16]byte{}, []byte("abc")) == Short64(seq, []byte("abc")) {
+		t.Fatal("different keys give the same short hash")
+	}
+}
+
+func TestHMACNilKey(t *testing.T) {
+	a := SumHMAC(nil, nil)
+	b := SumHMAC([]byte{}, []byte{})
+	c := SumHMAC(make([]byte, BlockSize), nil)
+	if !bytes.Equal(a[:], b[:]) || !bytes.Equal(a[:], c[:]) {
+		t.Fatalf("empty keys disagree: %x %x %x", a, b, c)
+	}
+}
+
+var hmacBenchKey = []byte(strings.Repeat("k", Size))
+
+func BenchmarkHMACKey(b *testing.B) {
+	k := NewHMACKey(hmacBenchKey)
+	b.SetBytes(64)
+	for i := 0; i < b.N; i++ {
+		k.Sum(buf[:64])
+	}
+}
+
+func BenchmarkSumHMAC(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(64)
+	for i := 0; i < b.N; i++ {
+		SumHMAC(hmacBenchKey, buf[:64])
+	}
+}
+
+func BenchmarkSumHMACInto(b *testing.B) {
+	b.ReportAllocs()
+	var dst [Size]byte
+	b.SetBytes(64)
+	for i := 0; i < b.N; i++ {
+		SumHMACInto(&dst, hmacBenchKey, buf[:64])
+	}
+}
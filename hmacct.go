@@ -0,0 +1,88 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"crypto/subtle"
+	"hash"
+)
+
+// HMACCTMaxKeyLen is the longest key NewHMACCT accepts.
+const HMACCTMaxKeyLen = 2 * BlockSize
+
+// ctKeyBlocks is the number of blocks needed to hash a key of
+// HMACCTMaxKeyLen bytes, including padding.
+const ctKeyBlocks = (HMACCTMaxKeyLen + 9 + BlockSize - 1) / BlockSize
+
+// NewHMACCT returns a new hash.Hash computing HMAC-SM3 with the given key,
+// like NewHMAC, but prepares the key in time independent of its length.
+//
+// RFC 2104 hashes keys longer than BlockSize and zero pads shorter ones,
+// so the time NewHMAC takes reveals which case applies and, for long keys,
+// how many blocks they span. NewHMACCT instead always compresses and
+// selects between the same fixed number of blocks, enough for keys of up
+// to HMACCTMaxKeyLen bytes, and panics for longer keys. It protects only
+// the key length against timing attacks; the key bytes and the message
+// are handled exactly as by NewHMAC, whose SM3 arithmetic is already
+// constant time.
+func NewHMACCT(key []byte) hash.Hash {
+	if len(key) > HMACCTMaxKeyLen {
+		panic(lengthError("NewHMACCT key longer than HMACCTMaxKeyLen"))
+	}
+	// The key block is exactly BlockSize bytes, so init uses it as is.
+	k := ctKeyBlock(key)
+	h := new(hmacDigest)
+	h.key.init(k[:])
+	h.Reset()
+	return h
+}
+
+// ctKeyBlock returns the RFC 2104 key block for key, SM3(key) if key is
+// longer than BlockSize and key itself otherwise, zero padded to
+// BlockSize, without branching on len(key).
+func ctKeyBlock(key []byte) [BlockSize]byte {
+	n := len(key)
+	var raw, msg [ctKeyBlocks * BlockSize]byte
+	copy(raw[:], key)
+
+	// Pad key as the message of SM3 would be, with the length field at the
+	// end of block last.
+	last := (n + 8) / BlockSize
+	bits := uint64(n) << 3
+	for i := range msg {
+		b := raw[i] | byte(subtle.ConstantTimeEq(int32(i), int32(n))<<7)
+		blk, off := i/BlockSize, i%BlockSize
+		if off >= BlockSize-8 {
+			inLen := subtle.ConstantTimeEq(int32(blk), int32(last))
+			b |= byte(inLen) * 0xff & byte(bits>>(8*uint(BlockSize-1-off)))
+		}
+		msg[i] = b
+	}
+
+	// Compress every block and keep the chaining value after block last.
+	var (
+		d digest
+		h [8]uint32
+	)
+	d.Reset()
+	for j := 0; j < ctKeyBlocks; j++ {
+		block(&d, msg[j*BlockSize:(j+1)*BlockSize])
+		m := uint32(-subtle.ConstantTimeEq(int32(j), int32(last)))
+		for i := range h {
+			h[i] = h[i]&^m | d.h[i]&m
+		}
+	}
+
+	long := byte(-(1 - subtle.ConstantTimeLessOrEq(n, BlockSize)))
+	var kb [BlockSize]byte
+	for i := range kb {
+		var hb byte
+		if i < Size {
+			hb = byte(h[i/4] >> (24 - 8*uint(i%4)))
+		}
+		kb[i] = hb&long | raw[i]&^long
+	}
+	return kb
+}
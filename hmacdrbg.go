@@ -0,0 +1,91 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+// An HMACDRBG is a deterministic random bit generator following the
+// HMAC_DRBG mechanism of NIST SP 800-90A with HMAC-SM3. It shares its
+// limits with DRBG: at least 32 bytes of entropy, at most 65536 bytes per
+// request and 2^48 requests between reseeds.
+type HMACDRBG struct {
+	k             HMACKey
+	v             [Size]byte
+	reseedCounter uint64
+}
+
+// NewHMACDRBG instantiates an HMACDRBG from entropy, which must be at least
+// 32 bytes, a nonce and an optional personalization string.
+func NewHMACDRBG(entropy, nonce, personalization []byte) (*HMACDRBG, error) {
+	if len(entropy) < drbgMinEntropy {
+		return nil, lengthError("DRBG entropy input too short")
+	}
+	d := new(HMACDRBG)
+	var k [Size]byte
+	d.k.init(k[:])
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(entropy, nonce, personalization)
+	d.reseedCounter = 1
+	return d, nil
+}
+
+// Reseed mixes fresh entropy, at least 32 bytes, and optional additional
+// input into the state and resets the reseed counter.
+func (d *HMACDRBG) Reseed(entropy, additional []byte) error {
+	if len(entropy) < drbgMinEntropy {
+		return lengthError("DRBG entropy input too short")
+	}
+	d.update(entropy, additional)
+	d.reseedCounter = 1
+	return nil
+}
+
+// Generate returns n pseudorandom bytes, mixing in the optional additional
+// input first. n may be at most 65536.
+func (d *HMACDRBG) Generate(n int, additional []byte) ([]byte, error) {
+	if n < 0 || n > drbgMaxRequest {
+		return nil, lengthError("DRBG request length out of range")
+	}
+	if d.reseedCounter > drbgReseedInterval {
+		return nil, ErrReseedRequired
+	}
+
+	if len(additional) > 0 {
+		d.update(additional)
+	}
+	out := make([]byte, n)
+	for i := 0; i < n; {
+		d.v = d.k.Sum(d.v[:])
+		i += copy(out[i:], d.v[:])
+	}
+	d.update(additional)
+	d.reseedCounter++
+	return out, nil
+}
+
+// update is the HMAC_DRBG_Update function, with the provided data being
+// the concatenation of data.
+func (d *HMACDRBG) update(data ...[]byte) {
+	empty := true
+	for _, b := range data {
+		if len(b) > 0 {
+			empty = false
+		}
+	}
+	for _, sep := range []byte{0x00, 0x01} {
+		h := d.k.inner
+		h.Write(d.v[:])
+		h.Write([]byte{sep})
+		for _, b := range data {
+			h.Write(b)
+		}
+		k := d.k.finish(&h)
+		d.k.init(k[:])
+		d.v = d.k.Sum(d.v[:])
+		if empty {
+			return
+		}
+	}
+}
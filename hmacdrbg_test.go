@@ -0,0 +1,71 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Known answers were computed with an independent HMAC_DRBG implementation.
+func TestHMACDRBG(t *testing.T) {
+	d, err := NewHMACDRBG(seq(0, 32), seq(32, 48), []byte("personalization"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		n          int
+		additional string
+		reseed     bool
+		want       string
+	}{
+		{64, "", false, "a83c68ac60fc8164390a32d5aa1b5bf4f35fe1d9da7cddaeb73a44019019431c" +
+			"b569f6cf58021b2912b293bcbe3ab4f8442ca44f882aa8a19edd1027812eb597"},
+		{100, "additional", false, "50c14a032b18bf980ba349fc60217c6807cd165de409b307c725542ad8b9dfbf" +
+			"a1bebc7a7c129980f573a791fe23c829afd878a33e1c04dab59eab696b0b5264" +
+			"a8c8873023e83be1b453a73d9e8826165a9aef3d837243b74a34c012e145c5b7c949a90c"},
+		{32, "", true, "5d61a3976dd35dafdcb977379b18f748ba2df045a82e2783e05aa21697e117b5"},
+	}
+	for i, s := range steps {
+		if s.reseed {
+			if err := d.Reseed(seq(48, 80), []byte("reseed")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		out, err := d.Generate(s.n, []byte(s.additional))
+		if err != nil {
+			t.Fatalf("#%d: Generate: %v", i, err)
+		}
+		if got := hex.EncodeToString(out); got != s.want {
+			t.Fatalf("#%d: Generate \ngot : %s \nwant: %s", i, got, s.want)
+		}
+	}
+}
+
+func TestHMACDRBGErrors(t *testing.T) {
+	if _, err := NewHMACDRBG(make([]byte, 31), nil, nil); err == nil {
+		t.Fatal("NewHMACDRBG accepted 31 bytes of entropy")
+	}
+	d, _ := NewHMACDRBG(make([]byte, 32), nil, nil)
+	if _, err := d.Generate(drbgMaxRequest+1, nil); err == nil {
+		t.Fatal("Generate accepted an oversized request")
+	}
+	if err := d.Reseed(nil, nil); err == nil {
+		t.Fatal("Reseed accepted empty entropy")
+	}
+
+	d.reseedCounter = drbgReseedInterval
+	if _, err := d.Generate(1, nil); err != nil {
+		t.Fatalf("last Generate before reseed: %v", err)
+	}
+	if _, err := d.Generate(1, nil); err != ErrReseedRequired {
+		t.Fatalf("Generate past reseed interval: got %v, want ErrReseedRequired", err)
+	}
+	d.Reseed(make([]byte, 32), nil)
+	if _, err := d.Generate(1, nil); err != nil {
+		t.Fatalf("Generate after Reseed: %v", err)
+	}
+}
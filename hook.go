@@ -0,0 +1,57 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "hash"
+
+// hookDigest is a digest that reports each block it compresses.
+type hookDigest struct {
+	digest
+	hook func(offset uint64)
+}
+
+// NewWithBlockHook returns a new hash.Hash computing the SM3 checksum that
+// calls hook once for every complete BlockSize bytes of input, with the
+// input offset just past that block: BlockSize, 2*BlockSize, and so on.
+// The hook is called before Write returns. Buffered bytes of a partial
+// block do not trigger it, and neither do the padding blocks of Sum.
+func NewWithBlockHook(hook func(offset uint64)) hash.Hash {
+	d := &hookDigest{hook: hook}
+	d.Reset()
+	return d
+}
+
+func (d *hookDigest) Write(p []byte) (int, error) {
+	before := d.len
+	n, _ := d.digest.Write(p)
+	d.fire(before)
+	return n, nil
+}
+
+func (d *hookDigest) WriteString(s string) (int, error) {
+	before := d.len
+	n, _ := d.digest.WriteString(s)
+	d.fire(before)
+	return n, nil
+}
+
+func (d *hookDigest) WriteByte(c byte) error {
+	before := d.len
+	d.digest.WriteByte(c)
+	d.fire(before)
+	return nil
+}
+
+// fire calls the hook for the blocks completed since the length was before.
+func (d *hookDigest) fire(before uint64) {
+	for off := before - before%chunk + chunk; off <= d.len; off += chunk {
+		d.hook(off)
+	}
+}
+
+func (d *hookDigest) Clone() hash.Hash {
+	d0 := *d
+	return &d0
+}
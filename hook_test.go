@@ -0,0 +1,46 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestNewWithBlockHook(t *testing.T) {
+	var offsets []uint64
+	h := NewWithBlockHook(func(off uint64) { offsets = append(offsets, off) })
+
+	data := testData(200)
+	for _, n := range []int{7, 0, 57, 1, 70, 65} {
+		h.Write(data[:n])
+		data = data[n:]
+	}
+	h.Sum(nil)
+	if want := []uint64{64, 128, 192}; !reflect.DeepEqual(offsets, want) {
+		t.Fatalf("hook offsets = %v, want %v", offsets, want)
+	}
+
+	h.Reset()
+	offsets = nil
+	io.WriteString(h, string(bytes.Repeat([]byte{'a'}, 130)))
+	if want := []uint64{64, 128}; !reflect.DeepEqual(offsets, want) {
+		t.Fatalf("after Reset, WriteString hook offsets = %v, want %v", offsets, want)
+	}
+	offsets = nil
+	bw := h.(io.ByteWriter)
+	for i := 0; i < 70; i++ {
+		bw.WriteByte('a')
+	}
+	if want := []uint64{192}; !reflect.DeepEqual(offsets, want) {
+		t.Fatalf("WriteByte hook offsets = %v, want %v", offsets, want)
+	}
+	want := Sum(bytes.Repeat([]byte{'a'}, 200))
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "encoding/binary"
+
+// maxKDFLen is the longest output a 32-bit counter can produce.
+const maxKDFLen = (1<<32 - 1) * Size
+
+// Initial counter values of the counter mode constructions.
+const (
+	kdfStart    = 1 // GM/T 0003.4 KDF and ISO/IEC 18033-2 KDF2
+	mgf1Start   = 0 // PKCS #1 MGF1 and ISO/IEC 18033-2 KDF1
+	pbkdf2Start = 1 // block index INT(i) of RFC 8018
+)
+
+// counter returns i as the 32-bit big-endian counter the KDFs append to
+// their inputs.
+func counter(i uint32) [4]byte {
+	var c [4]byte
+	binary.BigEndian.PutUint32(c[:], i)
+	return c
+}
+
+// counterHash fills out with
+//
+//	SM3(seed || counter(start)) || SM3(seed || counter(start+1)) || ...
+//
+// truncated to len(out) bytes.
+func counterHash(out, seed []byte, start uint32) {
+	var d digest
+	for i, n := start, 0; n < len(out); i++ {
+		d.Reset()
+		d.Write(seed)
+		c := counter(i)
+		d.Write(c[:])
+		sum := d.checkSum()
+		n += copy(out[n:], sum[:])
+	}
+}
+
+// KDF implements the key derivation function of GM/T 0003.4 (SM2).
+// It returns the first keyLen bytes of
+//
+//	SM3(z || ct1) || SM3(z || ct2) || ...
+//
+// where ct is a 32-bit big-endian counter starting at 1.
+// KDF panics if keyLen is negative or exceeds (2^32-1)*Size.
+func KDF(z []byte, keyLen int) []byte {
+	if keyLen < 0 {
+		panic(lengthError("KDF output length out of range"))
+	}
+	k := make([]byte, keyLen)
+	KDFInto(k, z)
+	return k
+}
+
+// KDFInto fills dst with the output of KDF(z, len(dst)) without
+// allocating.
+func KDFInto(dst, z []byte) {
+	if uint64(len(dst)) > maxKDFLen {
+		panic(lengthError("KDF output length out of range"))
+	}
+	counterHash(dst, z, kdfStart)
+}
+
+// KDF2 implements KDF2 of ISO/IEC 18033-2 with SM3 as the hash. It returns
+// the first length bytes of
+//
+//	SM3(seed || C1) || SM3(seed || C2) || ...
+//
+// where C is a 32-bit big-endian counter starting at 1. That is the same
+// construction as the SM2 KDF, so KDF2 and KDF agree; KDF1 of ISO/IEC
+// 18033-2 starts the counter at 0 and is provided as MGF1.
+// KDF2 panics if length is negative or exceeds (2^32-1)*Size.
+func KDF2(seed []byte, length int) []byte {
+	return KDF(seed, length)
+}
+
+// KDF2Into fills dst with the output of KDF2(seed, len(dst)) without
+// allocating.
+func KDF2Into(dst, seed []byte) {
+	KDFInto(dst, seed)
+}
+
+// MGF1 implements the mask generation function of PKCS #1 (RFC 8017,
+// appendix B.2.1) with SM3 as the hash. It returns the first length bytes
+// of
+//
+//	SM3(seed || C0) || SM3(seed || C1) || ...
+//
+// where C is a 32-bit big-endian counter starting at 0.
+// MGF1 panics if length is negative or exceeds 2^32*Size.
+func MGF1(seed []byte, length int) []byte {
+	if length < 0 {
+		panic(lengthError("MGF1 output length out of range"))
+	}
+	mask := make([]byte, length)
+	MGF1Into(mask, seed)
+	return mask
+}
+
+// MGF1Into fills dst with the output of MGF1(seed, len(dst)) without
+// allocating.
+func MGF1Into(dst, seed []byte) {
+	if uint64(len(dst)) > maxKDFLen+Size {
+		panic(lengthError("MGF1 output length out of range"))
+	}
+	counterHash(dst, seed, mgf1Start)
+}
+
+// PBKDF2 derives a keyLen byte key from password and salt as specified by
+// RFC 8018 (PKCS #5 v2.1), with HMAC-SM3 as the pseudorandom function and
+// iter iterations. PBKDF2 panics if iter or keyLen is not positive.
+func PBKDF2(password, salt []byte, iter, keyLen int) []byte {
+	if iter <= 0 {
+		panic(lengthError("PBKDF2 iteration count must be positive"))
+	}
+	if keyLen <= 0 || uint64(keyLen) > maxKDFLen {
+		panic(lengthError("PBKDF2 key length out of range"))
+	}
+
+	var k HMACKey
+	k.init(password)
+
+	dk := make([]byte, keyLen)
+	for i, n := uint32(pbkdf2Start), 0; n < keyLen; i++ {
+		d := k.inner
+		d.Write(salt)
+		ib := counter(i)
+		d.Write(ib[:])
+		u := k.finish(&d)
+		t := u
+		for j := 1; j < iter; j++ {
+			u = k.Sum(u[:])
+			for x := range t {
+				t[x] ^= u[x]
+			}
+		}
+		n += copy(dk[n:], t[:])
+	}
+	return dk
+}
+
+// HKDFExtract implements the extract step of HKDF (RFC 5869) with
+// HMAC-SM3 and returns the pseudorandom key. A nil salt is treated as Size
+// zero bytes.
+func HKDFExtract(salt, ikm []byte) [Size]byte {
+	if salt == nil {
+		salt = make([]byte, Size)
+	}
+	return SumHMAC(salt, ikm)
+}
+
+// HKDFExpand implements the expand step of HKDF (RFC 5869) with HMAC-SM3
+// and returns length bytes of output keying material. HKDFExpand panics if
+// length is negative or greater than 255*Size.
+func HKDFExpand(prk, info []byte, length int) []byte {
+	if length < 0 || length > 255*Size {
+		panic(lengthError("HKDF output length out of range"))
+	}
+	okm := make([]byte, length)
+	HKDFExpandInto(okm, prk, info)
+	return okm
+}
+
+// HKDFExpandInto fills dst with the output of HKDFExpand(prk, info,
+// len(dst)) without allocating.
+func HKDFExpandInto(dst, prk, info []byte) {
+	if len(dst) > 255*Size {
+		panic(lengthError("HKDF output length out of range"))
+	}
+
+	var k HMACKey
+	k.init(prk)
+
+	var t [Size]byte
+	for i, n := 1, 0; n < len(dst); i++ {
+		d := k.inner
+		if i > 1 {
+			d.Write(t[:])
+		}
+		d.Write(info)
+		d.Write([]byte{byte(i)})
+		t = k.finish(&d)
+		n += copy(dst[n:], t[:])
+	}
+}
+
+// HKDF derives length bytes from secret by HKDFExtract with salt followed
+// by HKDFExpand with info.
+func HKDF(secret, salt, info []byte, length int) []byte {
+	prk := HKDFExtract(salt, secret)
+	return HKDFExpand(prk[:], info, length)
+}
+
+// DeriveKey derives a length byte subkey of master for the purpose named
+// by label, as HKDF with a nil salt and info len(label) || label, the
+// length as an 8-byte big-endian integer. Distinct labels give unrelated
+// subkeys. DeriveKey panics if length is negative or greater than
+// 255*Size.
+func DeriveKey(master []byte, label string, length int) []byte {
+	info := make([]byte, 8+len(label))
+	binary.BigEndian.PutUint64(info, uint64(len(label)))
+	copy(info[8:], label)
+	return HKDF(master, nil, info, length)
+}
+
+// PRF returns length bytes of the TLS 1.2 pseudorandom function
+// P_SM3(secret, label + seed) from RFC 5246, section 5, with HMAC-SM3 as
+// the HMAC, as used by TLCP.
+func PRF(secret, label, seed []byte, length int) []byte {
+	if length < 0 {
+		panic(lengthError("PRF output length out of range"))
+	}
+
+	var k HMACKey
+	k.init(secret)
+
+	out := make([]byte, length)
+	var a, t [Size]byte
+	for i, n := 0, 0; n < length; i++ {
+		// A(i) = HMAC(secret, A(i-1)), with A(0) = label + seed.
+		d := k.inner
+		if i == 0 {
+			d.Write(label)
+			d.Write(seed)
+		} else {
+			d.Write(a[:])
+		}
+		a = k.finish(&d)
+
+		d = k.inner
+		d.Write(a[:])
+		d.Write(label)
+		d.Write(seed)
+		t = k.finish(&d)
+		n += copy(out[n:], t[:])
+	}
+	return out
+}
@@ -0,0 +1,380 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+type kdfTest struct {
+	z   string
+	len int
+	out string
+}
+
+var kdfGolden = []kdfTest{
+	// GM/T 0003.5 public key encryption example, x2 || y2 with klen = 152 bits.
+	{
+		"64d20d27d0632957f8028c1e024f6b02edf23102a566c932ae8bd613a8e865fe" +
+			"58d225eca784ae300a81a2d48281a828e1cedf11c4219099840265375077bf78",
+		19,
+		"006e30dae231b071dfad8aa379e90264491603",
+	},
+	{"", 0, ""},
+	{"", 1, "88"},
+	{"616263", 32, "fe1ea80dac6f100c33537bd24619ec7c72a1e8b1ffeaefb1eb52a37791fdaf61"},
+	{"616263", 33, "fe1ea80dac6f100c33537bd24619ec7c72a1e8b1ffeaefb1eb52a37791fdaf619d"},
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f" +
+			"404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60616263",
+		100,
+		"7256be0931ee006a0c2abf0f301fb3d16be504ed417238dae0bdb3fdfa90a934" +
+			"21191b6a9a887b460789f30e9bbeb322289ed0f5900df20d3bb23ca40c6b844d" +
+			"2aa736a520a953e7ff9fc85481c32459df2032e1f3f756d658d054dd28dffa19c9b864a4",
+	},
+}
+
+func TestKDF(t *testing.T) {
+	for i, g := range kdfGolden {
+		z, _ := hex.DecodeString(g.z)
+		k := KDF(z, g.len)
+		if len(k) != g.len {
+			t.Fatalf("#%d: len(KDF) = %d, want %d", i, len(k), g.len)
+		}
+		if s := hex.EncodeToString(k); s != g.out {
+			t.Fatalf("#%d: KDF \ngot : %s \nwant: %s", i, s, g.out)
+		}
+	}
+}
+
+func TestKDF2(t *testing.T) {
+	seed := []byte("shared secret")
+	want := "abba3265958e22acbe073638a32ef2ffbbcdedd8322a5f8357b5a64d3419b2e4" +
+		"59e4156e8922b226028b999860845eaf62343b121ec747ed642d99712162c0ad" +
+		"d2b074f53bd45908ed52b3dd23ee5855"
+	k := KDF2(seed, 80)
+	if s := hex.EncodeToString(k); s != want {
+		t.Fatalf("KDF2 \ngot : %s \nwant: %s", s, want)
+	}
+	if m := MGF1(seed, 80); bytes.Equal(k, m) {
+		t.Fatal("KDF2 and MGF1 (KDF1) agree, counters must start at 1 and 0")
+	}
+}
+
+func TestCounter(t *testing.T) {
+	if c := counter(0x01020304); c != [4]byte{1, 2, 3, 4} {
+		t.Fatalf("counter(0x01020304) = %x, want 01020304", c)
+	}
+	if c := counter(1); c != [4]byte{0, 0, 0, 1} {
+		t.Fatalf("counter(1) = %x, want 00000001", c)
+	}
+}
+
+func TestCounterStart(t *testing.T) {
+	seed := []byte("seed")
+	block := func(i uint32) []byte {
+		c := counter(i)
+		sum := Sum(append(append([]byte(nil), seed...), c[:]...))
+		return sum[:]
+	}
+	for _, tt := range []struct {
+		name  string
+		out   []byte
+		start uint32
+	}{
+		{"KDF", KDF(seed, 2*Size), 1},
+		{"KDF2", KDF2(seed, 2*Size), 1},
+		{"MGF1", MGF1(seed, 2*Size), 0},
+	} {
+		want := append(block(tt.start), block(tt.start+1)...)
+		if !bytes.Equal(tt.out, want) {
+			t.Fatalf("%s does not start its counter at %d", tt.name, tt.start)
+		}
+	}
+
+	password, salt := []byte("password"), []byte("salt")
+	c := counter(1)
+	u := SumHMAC(password, append(append([]byte(nil), salt...), c[:]...))
+	if dk := PBKDF2(password, salt, 1, Size); !bytes.Equal(dk, u[:]) {
+		t.Fatal("PBKDF2 does not start its block index at 1")
+	}
+}
+
+func TestKDFInvalidLength(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("KDF with negative length did not panic")
+		}
+	}()
+	KDF(nil, -1)
+}
+
+// Outputs were computed with an independent MGF1-SM3 implementation.
+var mgf1Golden = []kdfTest{
+	{"", 0, ""},
+	{"", 1, "af"},
+	{"616263", 20, "8b234e95725238301d31bbb2e34e3e2296bd14b7"},
+	{"616263", 32, "8b234e95725238301d31bbb2e34e3e2296bd14b77fdc5704e3066c9431131cac"},
+	{
+		"616263",
+		65,
+		"8b234e95725238301d31bbb2e34e3e2296bd14b77fdc5704e3066c9431131cac" +
+			"fe1ea80dac6f100c33537bd24619ec7c72a1e8b1ffeaefb1eb52a37791fdaf619d",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f" +
+			"404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f60616263",
+		100,
+		"95ee8f4d7c63e34737f71a06ba1494b3a28c144a4fa59ddf1b8e76b8a05efcfa" +
+			"7256be0931ee006a0c2abf0f301fb3d16be504ed417238dae0bdb3fdfa90a934" +
+			"21191b6a9a887b460789f30e9bbeb322289ed0f5900df20d3bb23ca40c6b844d2aa736a5",
+	},
+}
+
+func TestMGF1(t *testing.T) {
+	for i, g := range mgf1Golden {
+		seed, _ := hex.DecodeString(g.z)
+		m := MGF1(seed, g.len)
+		if len(m) != g.len {
+			t.Fatalf("#%d: len(MGF1) = %d, want %d", i, len(m), g.len)
+		}
+		if s := hex.EncodeToString(m); s != g.out {
+			t.Fatalf("#%d: MGF1 \ngot : %s \nwant: %s", i, s, g.out)
+		}
+	}
+}
+
+type pbkdf2Test struct {
+	password string
+	salt     string
+	iter     int
+	out      string
+}
+
+// Inputs follow RFC 6070, outputs were computed with an independent
+// PBKDF2-HMAC-SM3 implementation.
+var pbkdf2Golden = []pbkdf2Test{
+	{"password", "salt", 1, "4612f922a1fdcefaf4312fc6f8f3322b489cbf24f2ea361b44c2bd8fa2c6dcb0"},
+	{"password", "salt", 2, "fee723a2bc966e11dffb66133f4e8df577383c78ade30e3298edbd3e54ed85b7"},
+	{"password", "salt", 4096, "b6e8f2074c87432b78f62e5ced980fdff89e86af2f693dab1638e2b3683045dd"},
+	{
+		"passwordPASSWORDpassword",
+		"saltSALTsaltSALTsaltSALTsaltSALTsalt",
+		4096,
+		"3b6282ac8519f059e465abff0ea37b0dbfe6c672a76e6b805312d53900db630732ccc1a88fa5512a",
+	},
+	{"pass\x00word", "sa\x00lt", 4096, "5f936b2e356f06e2bb3932165821261c"},
+	{
+		strings.Repeat("\x01", 100),
+		"",
+		3,
+		"c1ba6ce763a9c9401315c0bf681f2d1fba3b5e9a973ad3c50a7701fe61c13804" +
+			"806348da464f4ef0439e0fff9db878c33d3ed52739899e9941e076bc2552fd406c",
+	},
+}
+
+func TestPBKDF2(t *testing.T) {
+	for i, g := range pbkdf2Golden {
+		dk := PBKDF2([]byte(g.password), []byte(g.salt), g.iter, len(g.out)/2)
+		if s := hex.EncodeToString(dk); s != g.out {
+			t.Fatalf("#%d: PBKDF2 \ngot : %s \nwant: %s", i, s, g.out)
+		}
+	}
+}
+
+func TestPBKDF2Invalid(t *testing.T) {
+	for _, tt := range []struct{ iter, keyLen int }{{0, 32}, {-1, 32}, {1, 0}, {1, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("PBKDF2(iter=%d, keyLen=%d) did not panic", tt.iter, tt.keyLen)
+				}
+			}()
+			PBKDF2(nil, nil, tt.iter, tt.keyLen)
+		}()
+	}
+}
+
+type hkdfTest struct {
+	ikm  string
+	salt string // "-" for nil
+	info string
+	prk  string
+	okm  string
+}
+
+// Inputs are those of RFC 5869 test cases 1-3, outputs were computed with an
+// independent HKDF-SM3 implementation.
+var hkdfGolden = []hkdfTest{
+	{
+		strings.Repeat("0b", 22),
+		"000102030405060708090a0b0c",
+		"f0f1f2f3f4f5f6f7f8f9",
+		"e0d6f7b0bd056327b7659f1f39ad850561fbcf4fb10fb58e88eafa55cf7cd01e",
+		"c69fe91b7aaee2dd5718d72dcaee0cce93f1b8e41f792da51261b6a517e68b36ed2c595572b01dfa359b",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f" +
+			"404142434445464748494a4b4c4d4e4f",
+		"606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f" +
+			"808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f" +
+			"a0a1a2a3a4a5a6a7a8a9aaabacadaeaf",
+		"b0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecf" +
+			"d0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeef" +
+			"f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		"1a43a7fedb2d111eb33babd0d256c272aa3262cdb12e6b43d4321ae8888485d5",
+		"c1226236bbdefa7921f9febe27b864f33e449201b436d8844ea53f58170dd642" +
+			"6defbd22ed1f3c5960f35523e62e3b6c0d657f2c61893436f539013199bfaef2" +
+			"5aafd1e7726ede927623a9f5cbb8885c7e5d",
+	},
+	{
+		strings.Repeat("0b", 22),
+		"-",
+		"",
+		"004fc37143377d072d74e82ff480e8d7937ec607411bc1ec65dd34401871ff9c",
+		"c8c91a38ae2fb3b023a7c38ce9f0748f28230d59b6b950ba3ba949bf0d713a5774815778801741cb2034",
+	},
+}
+
+func TestHKDF(t *testing.T) {
+	for i, g := range hkdfGolden {
+		ikm, _ := hex.DecodeString(g.ikm)
+		info, _ := hex.DecodeString(g.info)
+		var salt []byte
+		if g.salt != "-" {
+			salt, _ = hex.DecodeString(g.salt)
+		}
+
+		prk := HKDFExtract(salt, ikm)
+		if s := hex.EncodeToString(prk[:]); s != g.prk {
+			t.Fatalf("#%d: HKDFExtract \ngot : %s \nwant: %s", i, s, g.prk)
+		}
+		okm := HKDFExpand(prk[:], info, len(g.okm)/2)
+		if s := hex.EncodeToString(okm); s != g.okm {
+			t.Fatalf("#%d: HKDFExpand \ngot : %s \nwant: %s", i, s, g.okm)
+		}
+		if s := hex.EncodeToString(HKDF(ikm, salt, info, len(g.okm)/2)); s != g.okm {
+			t.Fatalf("#%d: HKDF \ngot : %s \nwant: %s", i, s, g.okm)
+		}
+	}
+}
+
+func TestHKDFExpandLength(t *testing.T) {
+	prk := make([]byte, Size)
+	if n := len(HKDFExpand(prk, nil, 255*Size)); n != 255*Size {
+		t.Fatalf("len(HKDFExpand) = %d, want %d", n, 255*Size)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("HKDFExpand longer than 255*Size did not panic")
+		}
+	}()
+	HKDFExpand(prk, nil, 255*Size+1)
+}
+
+func TestDeriveKey(t *testing.T) {
+	master := []byte("master key")
+	want := "4cd2c04fc54ad466f925058b533b348133d453e081235264a38b5dd4a6fd5eabf138014106d8410f"
+	if s := hex.EncodeToString(DeriveKey(master, "encryption", 40)); s != want {
+		t.Fatalf("\ngot : %s \nwant: %s", s, want)
+	}
+	enc := DeriveKey(master, "encryption", Size)
+	if !bytes.Equal(enc, DeriveKey(master, "encryption", Size)) {
+		t.Fatal("DeriveKey is not deterministic")
+	}
+	for _, label := range []string{"", "authentication", "encryption2", "Encryption"} {
+		if bytes.Equal(enc, DeriveKey(master, label, Size)) {
+			t.Fatalf("labels %q and %q give the same key", "encryption", label)
+		}
+	}
+}
+
+var prfGolden = []struct {
+	secret, label, seed string
+	out                 string
+}{
+	{
+		"736563726574",               // "secret"
+		"6d617374657220736563726574", // "master secret"
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f",
+		"13b6ceff004ee264f8fc1b1f0a09cb2154fd47e991e2eba6a536a8e40414cbd3" +
+			"4bdb6f2658303338b802d38816de0a65",
+	},
+	{
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f" +
+			"202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f" +
+			"404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f" +
+			"60616263",
+		"6b657920657870616e73696f6e", // "key expansion"
+		"73656564",                   // "seed"
+		"d6e7bd03e37c2749cdbc5588b90fb5f8d9c0b4bd7dd7d3d66f9b22482501564a" +
+			"d3acac2d853a28ac0536995e510caec0dde0c6d79df5e52186f62e04898a3f41" +
+			"edf3c739987871cd80cfb289e4284038baebf12c2bfcc0d1bcf6199b43964966" +
+			"c96a47c2",
+	},
+	{"", "", "", "7b"},
+}
+
+func TestPRF(t *testing.T) {
+	for i, g := range prfGolden {
+		secret, _ := hex.DecodeString(g.secret)
+		label, _ := hex.DecodeString(g.label)
+		seed, _ := hex.DecodeString(g.seed)
+		out := PRF(secret, label, seed, len(g.out)/2)
+		if s := hex.EncodeToString(out); s != g.out {
+			t.Fatalf("#%d: PRF \ngot : %s \nwant: %s", i, s, g.out)
+		}
+	}
+	if n := len(PRF(nil, nil, nil, 0)); n != 0 {
+		t.Fatalf("PRF of length 0 returned %d bytes", n)
+	}
+}
+
+func TestInto(t *testing.T) {
+	z := []byte("shared secret")
+	for _, n := range []int{0, 1, Size, 100} {
+		for _, tt := range []struct {
+			name string
+			want []byte
+			into func([]byte)
+		}{
+			{"KDF", KDF(z, n), func(dst []byte) { KDFInto(dst, z) }},
+			{"KDF2", KDF2(z, n), func(dst []byte) { KDF2Into(dst, z) }},
+			{"MGF1", MGF1(z, n), func(dst []byte) { MGF1Into(dst, z) }},
+			{"HKDFExpand", HKDFExpand(z, []byte("info"), n), func(dst []byte) { HKDFExpandInto(dst, z, []byte("info")) }},
+		} {
+			dst := make([]byte, n)
+			tt.into(dst)
+			if !bytes.Equal(dst, tt.want) {
+				t.Fatalf("%sInto of %d bytes \ngot : %x \nwant: %x", tt.name, n, dst, tt.want)
+			}
+			if a := testing.AllocsPerRun(10, func() { tt.into(dst) }); a > 0 {
+				t.Fatalf("%sInto allocates %v times, want 0", tt.name, a)
+			}
+		}
+	}
+}
+
+func BenchmarkKDF(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		KDF(buf[:64], 48)
+	}
+}
+
+func BenchmarkKDFInto(b *testing.B) {
+	b.ReportAllocs()
+	dst := make([]byte, 48)
+	for i := 0; i < b.N; i++ {
+		KDFInto(dst, buf[:64])
+	}
+}
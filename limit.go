@@ -0,0 +1,54 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "hash"
+
+// limitedDigest is a digest that refuses input beyond max bytes.
+type limitedDigest struct {
+	digest
+	max uint64
+}
+
+// NewLimited returns a new hash.Hash computing the SM3 checksum of at most
+// max bytes. A write that would take the total past max hashes nothing and
+// returns ErrInputTooLong, so the hash stays usable for the data accepted
+// so far.
+func NewLimited(max uint64) hash.Hash {
+	d := &limitedDigest{max: max}
+	d.Reset()
+	return d
+}
+
+// fits reports whether n more bytes stay within the limit.
+func (d *limitedDigest) fits(n int) bool {
+	return d.len <= d.max && uint64(n) <= d.max-d.len
+}
+
+func (d *limitedDigest) Write(p []byte) (int, error) {
+	if !d.fits(len(p)) {
+		return 0, ErrInputTooLong
+	}
+	return d.digest.Write(p)
+}
+
+func (d *limitedDigest) WriteString(s string) (int, error) {
+	if !d.fits(len(s)) {
+		return 0, ErrInputTooLong
+	}
+	return d.digest.WriteString(s)
+}
+
+func (d *limitedDigest) WriteByte(c byte) error {
+	if !d.fits(1) {
+		return ErrInputTooLong
+	}
+	return d.digest.WriteByte(c)
+}
+
+func (d *limitedDigest) Clone() hash.Hash {
+	d0 := *d
+	return &d0
+}
@@ -0,0 +1,61 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"hash"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewLimited(t *testing.T) {
+	const max = 100
+	data := testData(max + 1)
+	for _, n := range []int{max - 1, max} {
+		h := NewLimited(max)
+		if _, err := h.Write(data[:n]); err != nil {
+			t.Fatalf("writing %d of %d bytes: %v", n, max, err)
+		}
+		if got, want := h.Sum(nil), Sum(data[:n]); !bytes.Equal(got, want[:]) {
+			t.Fatalf("%d bytes \ngot : %x \nwant: %x", n, got, want)
+		}
+	}
+
+	h := NewLimited(max)
+	h.Write(data[:max-1])
+	if n, err := h.Write(data[:2]); n != 0 || err != ErrInputTooLong {
+		t.Fatalf("Write over the limit = %d, %v; want 0, %v", n, err, ErrInputTooLong)
+	}
+	if _, err := io.WriteString(h, "ab"); err != ErrInputTooLong {
+		t.Fatalf("WriteString over the limit: got %v, want %v", err, ErrInputTooLong)
+	}
+	if got, want := h.Sum(nil), Sum(data[:max-1]); !bytes.Equal(got, want[:]) {
+		t.Fatalf("rejected write changed the hash \ngot : %x \nwant: %x", got, want)
+	}
+	if err := h.(io.ByteWriter).WriteByte(0); err != nil {
+		t.Fatalf("WriteByte up to the limit: %v", err)
+	}
+	if err := h.(io.ByteWriter).WriteByte(0); err != ErrInputTooLong {
+		t.Fatalf("WriteByte over the limit: got %v, want %v", err, ErrInputTooLong)
+	}
+
+	c := h.(interface{ Clone() hash.Hash }).Clone()
+	if _, err := c.Write([]byte{0}); err != ErrInputTooLong {
+		t.Fatalf("Clone lost the limit: got %v, want %v", err, ErrInputTooLong)
+	}
+	h.Reset()
+	if _, err := io.WriteString(h, strings.Repeat("x", max)); err != nil {
+		t.Fatalf("after Reset: %v", err)
+	}
+}
+
+func TestNewLimitedCopy(t *testing.T) {
+	h := NewLimited(1000)
+	if _, err := io.Copy(h, bytes.NewReader(testData(5000))); err != ErrInputTooLong {
+		t.Fatalf("io.Copy: got %v, want %v", err, ErrInputTooLong)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+// Domain separation prefixes of the Merkle tree, so that a leaf can never
+// be confused with an internal node.
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// A merkleHasher computes the leaf and node hashes of a Merkle tree with
+// SM3, or with HMAC-SM3 if key is set.
+type merkleHasher struct {
+	key *HMACKey
+	d   digest
+}
+
+func (m *merkleHasher) start() {
+	if m.key == nil {
+		m.d.Reset()
+	} else {
+		m.d = m.key.inner
+	}
+}
+
+func (m *merkleHasher) finish() [Size]byte {
+	if m.key == nil {
+		return m.d.checkSum()
+	}
+	return m.key.finish(&m.d)
+}
+
+func (m *merkleHasher) leaf(leaf []byte) [Size]byte {
+	m.start()
+	m.d.Write([]byte{merkleLeafPrefix})
+	m.d.Write(leaf)
+	return m.finish()
+}
+
+func (m *merkleHasher) node(left, right *[Size]byte) [Size]byte {
+	m.start()
+	m.d.Write([]byte{merkleNodePrefix})
+	m.d.Write(left[:])
+	m.d.Write(right[:])
+	return m.finish()
+}
+
+// empty returns the root of a tree with no leaves, the hash of the empty
+// string. Every leaf and node hash input starts with a prefix byte, so it
+// cannot collide with the root of a non-empty tree.
+func (m *merkleHasher) empty() [Size]byte {
+	m.start()
+	return m.finish()
+}
+
+// levels returns every level of the tree, leaf hashes first and the root
+// last.
+func (m *merkleHasher) levels(leaves [][]byte) [][][Size]byte {
+	level := make([][Size]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = m.leaf(leaf)
+	}
+	levels := [][][Size]byte{level}
+	for len(level) > 1 {
+		next := make([][Size]byte, (len(level)+1)/2)
+		for i := 0; i+1 < len(level); i += 2 {
+			next[i/2] = m.node(&level[i], &level[i+1])
+		}
+		if len(level)%2 == 1 {
+			next[len(next)-1] = level[len(level)-1]
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// MerkleRoot returns the root of the binary Merkle tree over leaves.
+//
+// Leaves are hashed as SM3(0x00 || leaf) and internal nodes as
+// SM3(0x01 || left || right). When a level has an odd number of nodes the
+// last one is promoted to the next level unchanged, so the root of a single
+// leaf is its leaf hash. The root of no leaves is SM3 of the empty string,
+// as in RFC 6962, which differs from the root of any non-empty tree.
+func MerkleRoot(leaves [][]byte) [Size]byte {
+	var m merkleHasher
+	if len(leaves) == 0 {
+		return m.empty()
+	}
+	levels := m.levels(leaves)
+	return levels[len(levels)-1][0]
+}
+
+// KeyedMerkleRoot returns the root of the Merkle tree over leaves built
+// like MerkleRoot's, but with each leaf and node hash computed as HMAC-SM3
+// under key instead of SM3, so that only holders of key can compute it.
+// The root of no leaves is HMAC-SM3 of the empty string.
+func KeyedMerkleRoot(key []byte, leaves [][]byte) [Size]byte {
+	m := merkleHasher{key: NewHMACKey(key)}
+	if len(leaves) == 0 {
+		return m.empty()
+	}
+	levels := m.levels(leaves)
+	return levels[len(levels)-1][0]
+}
+
+// MerkleProof returns the sibling hashes on the path from leaves[index] to
+// the root, bottom up. Levels where the node is promoted contribute nothing.
+func MerkleProof(leaves [][]byte, index int) ([][Size]byte, error) {
+	if len(leaves) == 0 {
+		return nil, lengthError("no Merkle tree leaves")
+	}
+	if index < 0 || index >= len(leaves) {
+		return nil, lengthError("Merkle leaf index out of range")
+	}
+	var (
+		m     merkleHasher
+		proof [][Size]byte
+	)
+	for _, level := range m.levels(leaves) {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof reports whether leaf is at index in a tree of count
+// leaves with the given root, according to proof as returned by
+// MerkleProof.
+func VerifyMerkleProof(root [Size]byte, leaf []byte, index, count int, proof [][Size]byte) bool {
+	if index < 0 || index >= count {
+		return false
+	}
+	var m merkleHasher
+	h := m.leaf(leaf)
+	for n := count; n > 1; n = (n + 1) / 2 {
+		if sibling := index ^ 1; sibling < n {
+			if len(proof) == 0 {
+				return false
+			}
+			if index%2 == 1 {
+				h = m.node(&proof[0], &h)
+			} else {
+				h = m.node(&h, &proof[0])
+			}
+			proof = proof[1:]
+		}
+		index /= 2
+	}
+	return len(proof) == 0 && h == root
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"fmt"
+	"testing"
+)
+
+func testLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = []byte(fmt.Sprintf("leaf %d", i))
+	}
+	return leaves
+}
+
+func TestMerkleRoot(t *testing.T) {
+	leaf := func(b []byte) [Size]byte { return Sum(append([]byte{0}, b...)) }
+	node := func(l, r [Size]byte) [Size]byte { return Sum(append(append([]byte{1}, l[:]...), r[:]...)) }
+
+	leaves := testLeaves(3)
+	if got, want := MerkleRoot(leaves[:1]), leaf(leaves[0]); got != want {
+		t.Fatalf("single leaf root = %x, want %x", got, want)
+	}
+	want := node(node(leaf(leaves[0]), leaf(leaves[1])), leaf(leaves[2]))
+	if got := MerkleRoot(leaves); got != want {
+		t.Fatalf("three leaf root = %x, want %x", got, want)
+	}
+	empty := MerkleRoot(nil)
+	if want := Sum(nil); empty != want {
+		t.Fatalf("empty root = %x, want %x", empty, want)
+	}
+	if empty == MerkleRoot([][]byte{{}}) {
+		t.Fatal("empty tree has the root of a single empty leaf")
+	}
+	if VerifyMerkleProof(empty, nil, 0, 0, nil) {
+		t.Fatal("VerifyMerkleProof accepted a leaf of the empty tree")
+	}
+}
+
+func TestKeyedMerkleRoot(t *testing.T) {
+	key := []byte("tree key")
+	leaf := func(b []byte) [Size]byte { return SumHMAC(key, append([]byte{0}, b...)) }
+	node := func(l, r [Size]byte) [Size]byte { return SumHMAC(key, append(append([]byte{1}, l[:]...), r[:]...)) }
+
+	leaves := testLeaves(3)
+	want := node(node(leaf(leaves[0]), leaf(leaves[1])), leaf(leaves[2]))
+	if got := KeyedMerkleRoot(key, leaves); got != want {
+		t.Fatalf("three leaf root = %x, want %x", got, want)
+	}
+	if KeyedMerkleRoot([]byte("other key"), leaves) == want {
+		t.Fatal("different keys give the same root")
+	}
+	if KeyedMerkleRoot(key, leaves) == MerkleRoot(leaves) {
+		t.Fatal("keyed root equals the unkeyed root")
+	}
+	if got, want := KeyedMerkleRoot(key, nil), SumHMAC(key, nil); got != want {
+		t.Fatalf("empty root = %x, want %x", got, want)
+	}
+}
+
+func TestMerkleProof(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		leaves := testLeaves(n)
+		root := MerkleRoot(leaves)
+		for i := range leaves {
+			proof, err := MerkleProof(leaves, i)
+			if err != nil {
+				t.Fatalf("n=%d i=%d: %v", n, i, err)
+			}
+			if !VerifyMerkleProof(root, leaves[i], i, n, proof) {
+				t.Fatalf("n=%d i=%d: valid proof rejected", n, i)
+			}
+			if VerifyMerkleProof(root, []byte("forged"), i, n, proof) {
+				t.Fatalf("n=%d i=%d: forged leaf accepted", n, i)
+			}
+			if n > 1 && VerifyMerkleProof(root, leaves[i], (i+1)%n, n, proof) {
+				t.Fatalf("n=%d i=%d: proof accepted at wrong index", n, i)
+			}
+		}
+	}
+}
+
+func TestMerkleProofErrors(t *testing.T) {
+	if _, err := MerkleProof(nil, 0); err == nil {
+		t.Fatal("MerkleProof over no leaves succeeded")
+	}
+	leaves := testLeaves(4)
+	for _, i := range []int{-1, 4} {
+		if _, err := MerkleProof(leaves, i); err == nil {
+			t.Fatalf("MerkleProof with index %d succeeded", i)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"crypto/sha256"
+	"hash"
+)
+
+// A MultiHasher writes everything written to it to several hashes, so that
+// one pass over the input yields all of their checksums.
+type MultiHasher struct {
+	hashes []hash.Hash
+}
+
+// NewMulti returns a MultiHasher feeding algos.
+func NewMulti(algos ...hash.Hash) *MultiHasher {
+	return &MultiHasher{hashes: append([]hash.Hash(nil), algos...)}
+}
+
+// NewSM3AndSHA256 returns a MultiHasher computing SM3 and SHA-256, in that
+// order.
+func NewSM3AndSHA256() *MultiHasher {
+	return NewMulti(New(), sha256.New())
+}
+
+// Write writes p to every hash. It never returns an error.
+func (m *MultiHasher) Write(p []byte) (int, error) {
+	for _, h := range m.hashes {
+		h.Write(p)
+	}
+	return len(p), nil
+}
+
+// Sums returns the checksum of each hash, in the order they were passed to
+// NewMulti.
+func (m *MultiHasher) Sums() [][]byte {
+	sums := make([][]byte, len(m.hashes))
+	for i, h := range m.hashes {
+		sums[i] = h.Sum(nil)
+	}
+	return sums
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+)
+
+func TestMultiHasher(t *testing.T) {
+	data := testData(10000)
+	m := NewSM3AndSHA256()
+	if _, err := io.Copy(m, bytes.NewReader(data)); err != nil {
+		t.Fatalf("io.Copy: %v", err)
+	}
+	sums := m.Sums()
+	if len(sums) != 2 {
+		t.Fatalf("got %d sums, want 2", len(sums))
+	}
+	if want := Sum(data); !bytes.Equal(sums[0], want[:]) {
+		t.Fatalf("SM3 \ngot : %x \nwant: %x", sums[0], want)
+	}
+	if want := sha256.Sum256(data); !bytes.Equal(sums[1], want[:]) {
+		t.Fatalf("SHA-256 \ngot : %x \nwant: %x", sums[1], want)
+	}
+}
+
+func TestMultiHasherEmpty(t *testing.T) {
+	m := NewMulti()
+	if n, err := m.Write([]byte("abc")); n != 3 || err != nil {
+		t.Fatalf("Write = %d, %v; want 3, nil", n, err)
+	}
+	if sums := m.Sums(); len(sums) != 0 {
+		t.Fatalf("got %d sums, want 0", len(sums))
+	}
+}
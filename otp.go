@@ -0,0 +1,52 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HOTP returns the HMAC-based one-time password of RFC 4226 with HMAC-SM3
+// in place of HMAC-SHA-1: the MAC of the 8-byte big-endian counter under
+// key, dynamically truncated to 31 bits and reduced to digits decimal
+// digits, zero padded. digits must be between 6 and 10.
+func HOTP(key []byte, counter uint64, digits int) (string, error) {
+	if digits < 6 || digits > 10 {
+		return "", lengthError("OTP digits must be between 6 and 10")
+	}
+	var c [8]byte
+	binary.BigEndian.PutUint64(c[:], counter)
+	mac := SumHMAC(key, c[:])
+
+	// Dynamic truncation: the low nibble of the last byte selects four
+	// bytes, of which the top bit is dropped.
+	off := mac[Size-1] & 0x0f
+	bin := binary.BigEndian.Uint32(mac[off:]) & 0x7fffffff
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	s := strconv.FormatUint(uint64(bin)%mod, 10)
+	return strings.Repeat("0", digits-len(s)) + s, nil
+}
+
+// TOTP returns the time-based one-time password of RFC 6238 with HMAC-SM3,
+// the HOTP of the number of whole periods between the Unix epoch and t.
+// period must be a whole number of seconds, at least one, and t must not
+// precede the epoch.
+func TOTP(key []byte, t time.Time, period time.Duration, digits int) (string, error) {
+	if period < time.Second || period%time.Second != 0 {
+		return "", lengthError("TOTP period must be a whole number of seconds")
+	}
+	unix := t.Unix()
+	if unix < 0 {
+		return "", lengthError("TOTP time before the Unix epoch")
+	}
+	return HOTP(key, uint64(unix)/uint64(period/time.Second), digits)
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// The key is the RFC 4226 test secret; codes were computed with an
+// independent HMAC-SM3 implementation.
+var otpKey = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	for _, tt := range []struct {
+		counter uint64
+		digits  int
+		code    string
+	}{
+		{0, 6, "945255"},
+		{1, 6, "614758"},
+		{2, 6, "741643"},
+		{3, 6, "942892"},
+		{1, 8, "78614758"},
+		{1, 10, "0878614758"},
+	} {
+		code, err := HOTP(otpKey, tt.counter, tt.digits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Fatalf("HOTP(%d, %d) \ngot : %s \nwant: %s", tt.counter, tt.digits, code, tt.code)
+		}
+	}
+	for _, digits := range []int{0, 5, 11} {
+		if _, err := HOTP(otpKey, 0, digits); !errors.Is(err, ErrInvalidLength) {
+			t.Fatalf("HOTP(%d digits): got %v, want an error wrapping %v", digits, err, ErrInvalidLength)
+		}
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	for _, tt := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "78614758"},
+		{1111111109, "71578389"},
+		{2000000000, "77639624"},
+	} {
+		code, err := TOTP(otpKey, time.Unix(tt.unix, 0), 30*time.Second, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Fatalf("TOTP at %d \ngot : %s \nwant: %s", tt.unix, code, tt.code)
+		}
+	}
+	if _, err := TOTP(otpKey, time.Unix(59, 0), 0, 8); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("zero period: got %v, want an error wrapping %v", err, ErrInvalidLength)
+	}
+	if _, err := TOTP(otpKey, time.Unix(59, 0), 1500*time.Millisecond, 8); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("1.5s period: got %v, want an error wrapping %v", err, ErrInvalidLength)
+	}
+	if _, err := TOTP(otpKey, time.Unix(-1, 0), 30*time.Second, 8); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("time before the epoch: got %v, want an error wrapping %v", err, ErrInvalidLength)
+	}
+}
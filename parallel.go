@@ -0,0 +1,59 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// SumBatch returns the SM3 checksums of msgs, in the same order, reusing a
+// single digest. Unlike ParallelSum it runs on the calling goroutine, which
+// is cheaper for small batches.
+func SumBatch(msgs [][]byte) [][Size]byte {
+	sums := make([][Size]byte, len(msgs))
+	var d digest
+	for i, m := range msgs {
+		d.Reset()
+		d.Write(m)
+		d.checkSumInto(&sums[i])
+	}
+	return sums
+}
+
+// ParallelSum returns the SM3 checksums of msgs, in the same order,
+// computed by up to GOMAXPROCS goroutines.
+func ParallelSum(msgs [][]byte) [][Size]byte {
+	sums := make([][Size]byte, len(msgs))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(msgs) {
+		workers = len(msgs)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		next int64 = -1
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			var d digest
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(msgs) {
+					return
+				}
+				d.Reset()
+				d.Write(msgs[i])
+				sums[i] = d.checkSum()
+			}
+		}()
+	}
+	wg.Wait()
+	return sums
+}
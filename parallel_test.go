@@ -0,0 +1,89 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestSumBatch(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 100} {
+		msgs := make([][]byte, n)
+		for i := range msgs {
+			msgs[i] = []byte(fmt.Sprintf("message %d", i))
+		}
+		sums := SumBatch(msgs)
+		if len(sums) != n {
+			t.Fatalf("%d messages: got %d sums", n, len(sums))
+		}
+		for i, m := range msgs {
+			if want := Sum(m); sums[i] != want {
+				t.Fatalf("%d messages: sum %d = %x, want %x", n, i, sums[i], want)
+			}
+		}
+	}
+}
+
+func TestParallelSum(t *testing.T) {
+	for _, n := range []int{0, 1, 2, runtime.GOMAXPROCS(0) + 1, 1000} {
+		msgs := make([][]byte, n)
+		for i := range msgs {
+			msgs[i] = []byte(fmt.Sprintf("message %d", i))
+		}
+		sums := ParallelSum(msgs)
+		if len(sums) != n {
+			t.Fatalf("%d messages: got %d sums", n, len(sums))
+		}
+		for i, m := range msgs {
+			if want := Sum(m); sums[i] != want {
+				t.Fatalf("%d messages: sum %d = %x, want %x", n, i, sums[i], want)
+			}
+		}
+	}
+}
+
+func BenchmarkParallelSum(b *testing.B) {
+	msgs := make([][]byte, 1000)
+	for i := range msgs {
+		msgs[i] = make([]byte, 1024)
+	}
+	b.SetBytes(int64(len(msgs) * 1024))
+	for i := 0; i < b.N; i++ {
+		ParallelSum(msgs)
+	}
+}
+
+func benchmarkMsgs() [][]byte {
+	msgs := make([][]byte, 16)
+	for i := range msgs {
+		msgs[i] = make([]byte, 64)
+	}
+	return msgs
+}
+
+func BenchmarkSumBatch(b *testing.B) {
+	msgs := benchmarkMsgs()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msgs) * 64))
+	for i := 0; i < b.N; i++ {
+		SumBatch(msgs)
+	}
+}
+
+func BenchmarkSumBatchNaive(b *testing.B) {
+	msgs := benchmarkMsgs()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msgs) * 64))
+	for i := 0; i < b.N; i++ {
+		sums := make([][Size]byte, len(msgs))
+		for j, m := range msgs {
+			h := New()
+			h.Write(m)
+			copy(sums[j][:], h.Sum(nil))
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"hash"
+	"sync"
+)
+
+var digestPool = sync.Pool{
+	New: func() interface{} { return new(digest) },
+}
+
+// readBufSize is the size of the buffers the reader-based helpers read
+// into.
+const readBufSize = 32 * 1024
+
+// readBufPool holds *[readBufSize]byte. Using a fixed-size array type,
+// rather than slices, keeps buffers of any other size out of the pool.
+var readBufPool = sync.Pool{
+	New: func() interface{} { return new([readBufSize]byte) },
+}
+
+func getReadBuf() *[readBufSize]byte {
+	return readBufPool.Get().(*[readBufSize]byte)
+}
+
+func putReadBuf(b *[readBufSize]byte) {
+	readBufPool.Put(b)
+}
+
+// Get returns a reset SM3 hash.Hash from a shared pool. Return it with Put
+// once it is no longer needed.
+func Get() hash.Hash {
+	d := digestPool.Get().(*digest)
+	d.Reset()
+	return d
+}
+
+// Put resets h and returns it to the pool used by Get. The caller must not
+// use h afterwards. Hashes not created by this package are ignored.
+func Put(h hash.Hash) {
+	d, ok := h.(*digest)
+	if !ok {
+		return
+	}
+	d.Reset()
+	digestPool.Put(d)
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		msg := []byte(fmt.Sprintf("message %d", i))
+		h := Get()
+		h.Write(msg)
+		want := Sum(msg)
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("message %d: got %x, want %x", i, got, want)
+		}
+		// Leave some data buffered; Get must hand out a reset digest.
+		h.Write([]byte("left over"))
+		Put(h)
+	}
+}
+
+func TestPutForeign(t *testing.T) {
+	Put(sha256.New())
+	Put(NewWithPrefix([]byte("prefix")))
+	if _, ok := Get().(*digest); !ok {
+		t.Fatal("Get returned a foreign hash")
+	}
+}
+
+func BenchmarkPool(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h := Get()
+		h.Write(buf[:1024])
+		Put(h)
+	}
+}
+
+// newSink keeps the compiler from allocating BenchmarkNew's digests on the
+// stack, as a long-lived service would not be able to either.
+var newSink hash.Hash
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h := New()
+		h.Write(buf[:1024])
+		newSink = h
+	}
+}
+
+func TestReadBufPoolConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := testData(50000 + i*1000)
+			for j := 0; j < 10; j++ {
+				// LimitReader hides bytes.Reader's WriteTo so the pooled
+				// buffer is actually used.
+				sum, _, err := SumReaderLen(io.LimitReader(bytes.NewReader(data), 1<<30))
+				if err == nil && sum != Sum(data) {
+					err = fmt.Errorf("goroutine %d: wrong checksum", i)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkSumReadersParallel(b *testing.B) {
+	data := make([]byte, 4096)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			SumReaders(io.LimitReader(bytes.NewReader(data), 1<<30))
+		}
+	})
+}
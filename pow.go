@@ -0,0 +1,46 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// CheckPoW reports whether SM3(nonce || challenge) starts with at least
+// difficulty zero bits. A difficulty of zero or less is always met.
+func CheckPoW(challenge, nonce []byte, difficulty int) bool {
+	var d digest
+	d.Reset()
+	d.Write(nonce)
+	d.Write(challenge)
+	return leadingZeroBits(d.checkSum()) >= difficulty
+}
+
+// SolvePoW searches for a nonce meeting CheckPoW for challenge and
+// difficulty, trying the 8-byte big-endian encodings of 0, 1, 2, ... up to
+// maxAttempts of them. It returns false if none of them does.
+func SolvePoW(challenge []byte, difficulty int, maxAttempts uint64) ([]byte, bool) {
+	nonce := make([]byte, 8)
+	for i := uint64(0); i < maxAttempts; i++ {
+		binary.BigEndian.PutUint64(nonce, i)
+		if CheckPoW(challenge, nonce, difficulty) {
+			return nonce, true
+		}
+	}
+	return nil, false
+}
+
+// leadingZeroBits returns the number of leading zero bits of sum.
+func leadingZeroBits(sum [Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
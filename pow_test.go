@@ -0,0 +1,64 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestLeadingZeroBits(t *testing.T) {
+	for _, tt := range []struct {
+		prefix string
+		n      int
+	}{
+		{"80", 0},
+		{"7f", 1},
+		{"01", 7},
+		{"0080", 8},
+		{"0001", 15},
+		{"000000000f", 36},
+	} {
+		var sum [Size]byte
+		hex.Decode(sum[:], []byte(tt.prefix))
+		if n := leadingZeroBits(sum); n != tt.n {
+			t.Fatalf("leadingZeroBits(%s...) = %d, want %d", tt.prefix, n, tt.n)
+		}
+	}
+	if n := leadingZeroBits([Size]byte{}); n != 8*Size {
+		t.Fatalf("leadingZeroBits(0) = %d, want %d", n, 8*Size)
+	}
+}
+
+func TestCheckPoW(t *testing.T) {
+	// SM3(nonce || "challenge") = 0004c80e..., 13 leading zero bits.
+	challenge := []byte("challenge")
+	nonce := []byte{0, 0, 0, 0, 0, 0, 0x01, 0xda}
+	for difficulty := 0; difficulty <= 13; difficulty++ {
+		if !CheckPoW(challenge, nonce, difficulty) {
+			t.Fatalf("nonce fails difficulty %d", difficulty)
+		}
+	}
+	if CheckPoW(challenge, nonce, 14) {
+		t.Fatal("nonce passes difficulty 14")
+	}
+}
+
+func TestSolvePoW(t *testing.T) {
+	challenge := []byte("challenge")
+	nonce, ok := SolvePoW(challenge, 12, 1000)
+	if !ok {
+		t.Fatal("no nonce found")
+	}
+	if want := "00000000000001da"; hex.EncodeToString(nonce) != want {
+		t.Fatalf("\ngot : %x \nwant: %s", nonce, want)
+	}
+	if !CheckPoW(challenge, nonce, 12) {
+		t.Fatal("solution does not verify")
+	}
+	if _, ok := SolvePoW(challenge, 12, 474); ok {
+		t.Fatal("found a nonce beyond the attempt budget")
+	}
+}
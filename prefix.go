@@ -0,0 +1,119 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "hash"
+
+// prefixDigest is a digest that resets to the state after absorbing a
+// fixed prefix rather than to the initial value.
+type prefixDigest struct {
+	digest
+	initial digest
+}
+
+// NewWithPrefix returns a new hash.Hash computing SM3(prefix || data) for
+// the data written to it. Reset returns it to the state right after the
+// prefix, so the prefix only has to be absorbed once. This is meant for
+// binding hashes to a context label, it is not a MAC; use NewHMAC for that.
+//
+// The marshaled state includes the state after the prefix, so a resumed
+// hash still resets to it. Wipe clears the data written so far but keeps
+// the prefix state, and Reset makes the hash usable again.
+func NewWithPrefix(prefix []byte) hash.Hash {
+	d := new(prefixDigest)
+	d.initial.Reset()
+	d.initial.Write(prefix)
+	d.Reset()
+	return d
+}
+
+func (d *prefixDigest) Reset() {
+	d.digest = d.initial
+}
+
+func (d *prefixDigest) SumReset(b []byte) []byte {
+	b = d.Sum(b)
+	d.Reset()
+	return b
+}
+
+func (d *prefixDigest) Clone() hash.Hash {
+	d0 := *d
+	return &d0
+}
+
+func (d *prefixDigest) Wipe() {
+	d.digest.Wipe()
+}
+
+// The marshaled state of a prefixed hash is prefixMagic followed by the
+// marshaled digest states after the prefix and after the data so far.
+const (
+	prefixMagic         = "sm3pfx\x01"
+	prefixMarshaledSize = len(prefixMagic) + 2*marshaledSize
+)
+
+func (d *prefixDigest) MarshalBinary() ([]byte, error) {
+	return d.AppendBinary(make([]byte, 0, prefixMarshaledSize))
+}
+
+func (d *prefixDigest) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, prefixMagic...)
+	b, _ = d.initial.AppendBinary(b)
+	return d.digest.AppendBinary(b)
+}
+
+func (d *prefixDigest) UnmarshalBinary(b []byte) error {
+	if len(b) < len(prefixMagic) || string(b[:len(prefixMagic)]) != prefixMagic {
+		return stateError("bad prefix identifier")
+	}
+	if len(b) != prefixMarshaledSize {
+		return stateError("bad prefix size")
+	}
+	b = b[len(prefixMagic):]
+	var initial, cur digest
+	if err := initial.UnmarshalBinary(b[:marshaledSize]); err != nil {
+		return err
+	}
+	if err := cur.UnmarshalBinary(b[marshaledSize:]); err != nil {
+		return err
+	}
+	if cur.len < initial.len {
+		return stateError("data state precedes the prefix")
+	}
+	d.initial, d.digest = initial, cur
+	return nil
+}
+
+func (d *prefixDigest) GobEncode() ([]byte, error) {
+	return d.MarshalBinary()
+}
+
+func (d *prefixDigest) GobDecode(b []byte) error {
+	return d.UnmarshalBinary(b)
+}
+
+// A Template holds the SM3 state after absorbing a fixed context, from
+// which any number of checksums SM3(context || data) are finished without
+// absorbing the context again. It is safe for concurrent use.
+type Template struct {
+	d digest
+}
+
+// NewTemplate returns a Template for context.
+func NewTemplate(context []byte) *Template {
+	t := new(Template)
+	t.d.Reset()
+	t.d.Write(context)
+	return t
+}
+
+// Hash returns SM3(context || data), working on a copy of the template's
+// state.
+func (t *Template) Hash(data []byte) [Size]byte {
+	d := t.d
+	d.Write(data)
+	return d.checkSum()
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"hash"
+	"strings"
+	"testing"
+)
+
+func TestNewWithPrefix(t *testing.T) {
+	for _, prefix := range []string{"", "label", strings.Repeat("long label ", 10)} {
+		h := NewWithPrefix([]byte(prefix))
+		for _, x := range []string{"", "abc", strings.Repeat("x", 200)} {
+			h.Write([]byte(x))
+			want := Sum([]byte(prefix + x))
+			if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+				t.Fatalf("prefix %q, data %q: got %x, want %x", prefix, x, got, want)
+			}
+			h.Reset()
+		}
+	}
+}
+
+func TestNewWithPrefixClone(t *testing.T) {
+	h := NewWithPrefix([]byte("label"))
+	h.Write([]byte("abc"))
+	c := h.(interface{ Clone() hash.Hash }).Clone()
+	c.Write([]byte("def"))
+	c.Reset()
+	c.Write([]byte("xyz"))
+	want := Sum([]byte("labelxyz"))
+	if got := c.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("clone after Reset: got %x, want %x", got, want)
+	}
+	want = Sum([]byte("labelabc"))
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("original: got %x, want %x", got, want)
+	}
+}
+
+func TestNewWithPrefixMarshal(t *testing.T) {
+	h := NewWithPrefix([]byte("label"))
+	h.Write([]byte("abc"))
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewWithPrefix([]byte("other label"))
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		t.Fatal(err)
+	}
+	r.Write([]byte("def"))
+	if got, want := r.Sum(nil), Sum([]byte("labelabcdef")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("resumed \ngot : %x \nwant: %x", got, want)
+	}
+	r.Reset()
+	r.Write([]byte("xyz"))
+	if got, want := r.Sum(nil), Sum([]byte("labelxyz")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("resumed after Reset \ngot : %x \nwant: %x", got, want)
+	}
+
+	plain, _ := New().(encoding.BinaryMarshaler).MarshalBinary()
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(plain); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("plain state: got %v, want an error wrapping %v", err, ErrInvalidState)
+	}
+	if err := New().(encoding.BinaryUnmarshaler).UnmarshalBinary(state); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("prefixed state into New: got %v, want an error wrapping %v", err, ErrInvalidState)
+	}
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(state[:len(state)-1]); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("truncated state: got %v, want an error wrapping %v", err, ErrInvalidState)
+	}
+	behind := append(append([]byte(nil), state[:len(prefixMagic)+marshaledSize]...), plain...)
+	if err := r.(encoding.BinaryUnmarshaler).UnmarshalBinary(behind); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("data state before the prefix: got %v, want an error wrapping %v", err, ErrInvalidState)
+	}
+}
+
+func TestNewWithPrefixWipe(t *testing.T) {
+	h := NewWithPrefix([]byte("label"))
+	h.Write([]byte("secret"))
+	h.(interface{ Wipe() }).Wipe()
+	if d := h.(*prefixDigest).digest; d != (digest{}) {
+		t.Fatalf("state after Wipe = %+v, want all zero", d)
+	}
+	h.Reset()
+	h.Write([]byte("abc"))
+	if got, want := h.Sum(nil), Sum([]byte("labelabc")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("after Wipe and Reset \ngot : %x \nwant: %x", got, want)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	for _, n := range []int{0, 5, 64, 100} {
+		context := testData(n)
+		tmpl := NewTemplate(context)
+		for _, data := range []string{"", "a", "second item", strings.Repeat("x", 200), "a"} {
+			want := Sum(append(append([]byte(nil), context...), data...))
+			if got := tmpl.Hash([]byte(data)); got != want {
+				t.Fatalf("context %d bytes, data %q \ngot : %x \nwant: %x", n, data, got, want)
+			}
+		}
+	}
+}
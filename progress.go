@@ -0,0 +1,110 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"hash"
+	"io"
+)
+
+// progressInterval is how many bytes a progress digest hashes between
+// callbacks.
+const progressInterval = 1 << 20
+
+// progressDigest is a digest that reports how much it has hashed.
+type progressDigest struct {
+	digest
+	cb       func(bytesHashed uint64)
+	reported uint64
+}
+
+// NewWithProgress returns a new hash.Hash computing the SM3 checksum that
+// calls cb with the total number of bytes hashed each time another MiB has
+// been written, and from Sum if anything was written since the last call.
+// Every method that writes or finishes the hash reports, including
+// WriteString, WriteByte, HexSum, Base64Sum and WriteSum. cb runs on the
+// writing goroutine and is never called for empty writes.
+// If cb is nil NewWithProgress is equivalent to New.
+func NewWithProgress(cb func(bytesHashed uint64)) hash.Hash {
+	if cb == nil {
+		return New()
+	}
+	d := &progressDigest{cb: cb}
+	d.Reset()
+	return d
+}
+
+func (d *progressDigest) Write(p []byte) (int, error) {
+	n, _ := d.digest.Write(p)
+	if d.len/progressInterval != d.reported/progressInterval {
+		d.report()
+	}
+	return n, nil
+}
+
+func (d *progressDigest) WriteString(s string) (int, error) {
+	n, _ := d.digest.WriteString(s)
+	if d.len/progressInterval != d.reported/progressInterval {
+		d.report()
+	}
+	return n, nil
+}
+
+func (d *progressDigest) WriteByte(c byte) error {
+	d.digest.WriteByte(c)
+	if d.len/progressInterval != d.reported/progressInterval {
+		d.report()
+	}
+	return nil
+}
+
+func (d *progressDigest) Sum(b []byte) []byte {
+	d.finish()
+	return d.digest.Sum(b)
+}
+
+func (d *progressDigest) HexSum() string {
+	d.finish()
+	return d.digest.HexSum()
+}
+
+func (d *progressDigest) Base64Sum() string {
+	d.finish()
+	return d.digest.Base64Sum()
+}
+
+func (d *progressDigest) WriteSum(w io.Writer) (int64, error) {
+	d.finish()
+	return d.digest.WriteSum(w)
+}
+
+func (d *progressDigest) SumReset(b []byte) []byte {
+	b = d.Sum(b)
+	d.Reset()
+	return b
+}
+
+// finish reports the final total before a checksum if anything was written
+// since the last report.
+func (d *progressDigest) finish() {
+	if d.len != d.reported {
+		d.report()
+	}
+}
+
+func (d *progressDigest) report() {
+	d.reported = d.len
+	d.cb(d.len)
+}
+
+func (d *progressDigest) Reset() {
+	d.digest.Reset()
+	d.reported = 0
+}
+
+func (d *progressDigest) Clone() hash.Hash {
+	d0 := *d
+	return &d0
+}
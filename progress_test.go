@@ -0,0 +1,95 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestNewWithProgress(t *testing.T) {
+	var calls []uint64
+	h := NewWithProgress(func(n uint64) { calls = append(calls, n) })
+
+	data := testData(3*progressInterval + 1000)
+	for p := data; len(p) > 0; {
+		n := 300000
+		if n > len(p) {
+			n = len(p)
+		}
+		h.Write(p[:n])
+		h.Write(nil)
+		p = p[n:]
+	}
+	if len(calls) != 3 {
+		t.Fatalf("got %d callbacks before Sum, want 3: %v", len(calls), calls)
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i] <= calls[i-1] {
+			t.Fatalf("callback totals not increasing: %v", calls)
+		}
+	}
+
+	want := Sum(data)
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+	if got := calls[len(calls)-1]; got != uint64(len(data)) {
+		t.Fatalf("final callback reported %d bytes, want %d", got, len(data))
+	}
+
+	// Nothing new was written, so a second Sum must not report again.
+	n := len(calls)
+	h.Sum(nil)
+	if len(calls) != n {
+		t.Fatalf("Sum without new data called back: %v", calls[n:])
+	}
+}
+
+func TestNewWithProgressWriteString(t *testing.T) {
+	var total uint64
+	h := NewWithProgress(func(n uint64) { total = n })
+	io.WriteString(h, "abc")
+	h.Sum(nil)
+	if total != 3 {
+		t.Fatalf("reported %d bytes, want 3", total)
+	}
+	h.Reset()
+	h.Sum(nil)
+	if total != 3 {
+		t.Fatalf("Sum after Reset reported %d bytes", total)
+	}
+}
+
+func TestNewWithProgressAllWriters(t *testing.T) {
+	var calls []uint64
+	h := NewWithProgress(func(n uint64) { calls = append(calls, n) })
+	io.WriteString(h, strings.Repeat("x", progressInterval+1))
+	if len(calls) != 1 || calls[0] != progressInterval+1 {
+		t.Fatalf("io.WriteString reported %v, want [%d]", calls, progressInterval+1)
+	}
+	d := h.(*progressDigest)
+	for name, finish := range map[string]func(){
+		"HexSum":    func() { d.HexSum() },
+		"Base64Sum": func() { d.Base64Sum() },
+		"WriteSum":  func() { d.WriteSum(ioutil.Discard) },
+	} {
+		calls = calls[:1]
+		h.(io.ByteWriter).WriteByte('y')
+		finish()
+		if len(calls) != 2 || calls[1] != d.len {
+			t.Fatalf("%s reported %v, want a final total of %d", name, calls, d.len)
+		}
+	}
+}
+
+func TestNewWithProgressNil(t *testing.T) {
+	if _, ok := NewWithProgress(nil).(*digest); !ok {
+		t.Fatal("NewWithProgress(nil) is not a plain digest")
+	}
+}
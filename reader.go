@@ -0,0 +1,253 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// A Reader hashes the data read through it.
+type Reader struct {
+	r io.Reader
+	d digest
+}
+
+// NewReader returns a Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	hr := &Reader{r: r}
+	hr.d.Reset()
+	return hr
+}
+
+// Read reads from the underlying reader and hashes the bytes returned,
+// including those returned together with an error.
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.d.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the SM3 checksum of the data read so far.
+func (r *Reader) Sum() [Size]byte {
+	return r.d.checkSum()
+}
+
+// An HMACReader computes the HMAC-SM3 of the data read through it.
+type HMACReader struct {
+	r     io.Reader
+	key   HMACKey
+	inner digest
+}
+
+// NewHMACReader returns an HMACReader reading from r and authenticating
+// under key.
+func NewHMACReader(key []byte, r io.Reader) *HMACReader {
+	hr := &HMACReader{r: r}
+	hr.key.init(key)
+	hr.inner = hr.key.inner
+	return hr
+}
+
+// Read reads from the underlying reader and authenticates the bytes
+// returned, including those returned together with an error.
+func (r *HMACReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.inner.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the HMAC-SM3 of the data read so far.
+func (r *HMACReader) Sum() [Size]byte {
+	d := r.inner
+	return r.key.finish(&d)
+}
+
+// ErrDigestMismatch is returned by a reader from NewVerifyingReader in place
+// of io.EOF when the data read does not have the expected checksum.
+var ErrDigestMismatch = errors.New("sm3: digest mismatch")
+
+type verifyingReader struct {
+	r        *Reader
+	expected [Size]byte
+}
+
+// NewVerifyingReader returns a reader that passes through the data of r
+// and, once r reports io.EOF, returns ErrDigestMismatch instead if the SM3
+// checksum of everything read differs from expected. Errors other than
+// io.EOF are passed through unchanged.
+func NewVerifyingReader(r io.Reader, expected [Size]byte) io.Reader {
+	return &verifyingReader{r: NewReader(r), expected: expected}
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	if err == io.EOF && v.r.Sum() != v.expected {
+		err = ErrDigestMismatch
+	}
+	return n, err
+}
+
+// SumReaderContext returns the SM3 checksum of everything read from r until
+// io.EOF. ctx is checked between reads, and if it is done ctx.Err() is
+// returned instead of a checksum.
+func SumReaderContext(ctx context.Context, r io.Reader) ([Size]byte, error) {
+	var d digest
+	d.Reset()
+	buf := getReadBuf()
+	defer putReadBuf(buf)
+	for {
+		if err := ctx.Err(); err != nil {
+			return [Size]byte{}, err
+		}
+		n, err := r.Read(buf[:])
+		d.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return [Size]byte{}, err
+		}
+	}
+	return d.checkSum(), nil
+}
+
+// SumReaders returns the SM3 checksum of the concatenation of everything
+// read from readers, in order, until each reports io.EOF. It stops at the
+// first read error and returns it.
+func SumReaders(readers ...io.Reader) ([Size]byte, error) {
+	var d digest
+	d.Reset()
+	buf := getReadBuf()
+	defer putReadBuf(buf)
+	for _, r := range readers {
+		if _, err := io.CopyBuffer(&d, r, buf[:]); err != nil {
+			return [Size]byte{}, err
+		}
+	}
+	return d.checkSum(), nil
+}
+
+// SumReaderLen returns the SM3 checksum of everything read from r until
+// io.EOF and the number of bytes read. On a read error it returns the
+// error and the number of bytes read before it.
+func SumReaderLen(r io.Reader) (sum [Size]byte, n int64, err error) {
+	var d digest
+	d.Reset()
+	buf := getReadBuf()
+	defer putReadBuf(buf)
+	n, err = io.CopyBuffer(&d, r, buf[:])
+	if err != nil {
+		return [Size]byte{}, n, err
+	}
+	return d.checkSum(), n, nil
+}
+
+// CopyHash copies from src to dst until io.EOF or an error, like io.Copy,
+// and returns the SM3 checksum of the bytes copied along with their number.
+// On the first read or write error it stops and returns that error and the
+// number of bytes written before it.
+func CopyHash(dst io.Writer, src io.Reader) (sum [Size]byte, written int64, err error) {
+	var d digest
+	d.Reset()
+	buf := getReadBuf()
+	defer putReadBuf(buf)
+	written, err = io.CopyBuffer(io.MultiWriter(dst, &d), src, buf[:])
+	if err != nil {
+		return [Size]byte{}, written, err
+	}
+	return d.checkSum(), written, nil
+}
+
+// SumChan returns the SM3 checksum of the concatenation of the chunks
+// received from ch until it is closed. Nil and empty chunks contribute
+// nothing.
+func SumChan(ch <-chan []byte) [Size]byte {
+	var d digest
+	d.Reset()
+	for p := range ch {
+		d.Write(p)
+	}
+	return d.checkSum()
+}
+
+// SumRegion returns the SM3 checksum of the length bytes of r starting at
+// offset off. It returns io.ErrUnexpectedEOF if r ends before the region
+// does, and an error wrapping ErrInvalidLength if off or length is
+// negative.
+func SumRegion(r io.ReaderAt, off, length int64) ([Size]byte, error) {
+	if off < 0 || length < 0 {
+		return [Size]byte{}, lengthError("negative region offset or length")
+	}
+	var d digest
+	d.Reset()
+	buf := getReadBuf()
+	defer putReadBuf(buf)
+	n, err := io.CopyBuffer(&d, io.NewSectionReader(r, off, length), buf[:])
+	if err != nil {
+		return [Size]byte{}, err
+	}
+	if n < length {
+		return [Size]byte{}, io.ErrUnexpectedEOF
+	}
+	return d.checkSum(), nil
+}
+
+// ErrLimitExceeded is returned by a MeteredReader that has reached its
+// limit when the underlying reader has more data.
+var ErrLimitExceeded = errors.New("sm3: read limit exceeded")
+
+// A MeteredReader hashes and counts the data read through it and fails
+// with ErrLimitExceeded if the underlying reader holds more than a limit.
+type MeteredReader struct {
+	r        io.Reader
+	d        digest
+	limit    int64
+	exceeded bool
+}
+
+// NewMeteredReader returns a MeteredReader reading at most limit bytes
+// from r.
+func NewMeteredReader(r io.Reader, limit int64) *MeteredReader {
+	mr := &MeteredReader{r: r, limit: limit}
+	mr.d.Reset()
+	return mr
+}
+
+// Read reads from the underlying reader. Once limit bytes have been read,
+// it reads one byte further to tell whether r has ended, and if not
+// returns ErrLimitExceeded; that byte is neither returned nor hashed.
+// Later calls return ErrLimitExceeded without reading.
+func (r *MeteredReader) Read(p []byte) (int, error) {
+	if r.exceeded {
+		return 0, ErrLimitExceeded
+	}
+	left := r.limit - r.Count()
+	if left < 0 {
+		left = 0
+	}
+	if left < int64(len(p)) {
+		p = p[:left+1]
+	}
+	n, err := r.r.Read(p)
+	if int64(n) > left {
+		n = int(left)
+		err = ErrLimitExceeded
+		r.exceeded = true
+	}
+	r.d.Write(p[:n])
+	return n, err
+}
+
+// Count returns the number of bytes read so far.
+func (r *MeteredReader) Count() int64 {
+	return int64(r.d.len)
+}
+
+// Sum returns the checksum of the data read so far.
+func (r *MeteredReader) Sum() [Size]byte {
+	return r.d.checkSum()
+}
@@ -0,0 +1,371 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func testData(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(data)
+	return data
+}
+
+// errReader is a reader that always fails with err, like iotest.ErrReader
+// on Go 1.16 and later.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestReader(t *testing.T) {
+	data := testData(10000)
+	want := Sum(data)
+
+	for name, src := range map[string]func() io.Reader{
+		"plain":   func() io.Reader { return bytes.NewReader(data) },
+		"onebyte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+		"half":    func() io.Reader { return iotest.HalfReader(bytes.NewReader(data)) },
+		"dataerr": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) },
+		"timeout": func() io.Reader { return iotest.TimeoutReader(bytes.NewReader(data)) },
+	} {
+		r := NewReader(src())
+		var out []byte
+		buf := make([]byte, 97)
+		for i := 0; ; i++ {
+			n, err := r.Read(buf[:1+i%len(buf)])
+			out = append(out, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil && err != iotest.ErrTimeout {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if !bytes.Equal(out, data) {
+			t.Fatalf("%s: read data differs", name)
+		}
+		if got := r.Sum(); got != want {
+			t.Fatalf("%s: Sum = %x, want %x", name, got, want)
+		}
+	}
+}
+
+func TestHMACReader(t *testing.T) {
+	key := []byte("download key")
+	data := testData(10000)
+	mac := NewHMAC(key)
+	mac.Write(data)
+	want := mac.Sum(nil)
+
+	for name, src := range map[string]func() io.Reader{
+		"plain":   func() io.Reader { return bytes.NewReader(data) },
+		"onebyte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+		"dataerr": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) },
+	} {
+		r := NewHMACReader(key, src())
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Fatalf("%s: read data differs", name)
+		}
+		if got := r.Sum(); !bytes.Equal(got[:], want) {
+			t.Fatalf("%s: Sum = %x, want %x", name, got, want)
+		}
+		// Sum does not consume the state.
+		if got := r.Sum(); !bytes.Equal(got[:], want) {
+			t.Fatalf("%s: second Sum = %x, want %x", name, got, want)
+		}
+	}
+}
+
+func TestReaderZeroLength(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte("abc")))
+	if n, err := r.Read(nil); n != 0 || err != nil {
+		t.Fatalf("zero length Read = %d, %v", n, err)
+	}
+	io.Copy(ioutil.Discard, r)
+	if got, want := r.Sum(), Sum([]byte("abc")); got != want {
+		t.Fatalf("Sum = %x, want %x", got, want)
+	}
+}
+
+func TestVerifyingReader(t *testing.T) {
+	data := testData(1000)
+	sum := Sum(data)
+	tampered := append([]byte(nil), data...)
+	tampered[500] ^= 1
+
+	for _, tt := range []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"match", data, nil},
+		{"tampered", tampered, ErrDigestMismatch},
+		{"short", data[:999], ErrDigestMismatch},
+		{"long", append(data[:len(data):len(data)], 0), ErrDigestMismatch},
+	} {
+		r := NewVerifyingReader(iotest.DataErrReader(bytes.NewReader(tt.data)), sum)
+		var out []byte
+		buf := make([]byte, 64)
+		var err error
+		for {
+			var n int
+			n, err = r.Read(buf)
+			out = append(out, buf[:n]...)
+			if err != nil {
+				break
+			}
+		}
+		if !bytes.Equal(out, tt.data) {
+			t.Fatalf("%s: data not passed through", tt.name)
+		}
+		if tt.want == nil && err != io.EOF || tt.want != nil && err != tt.want {
+			t.Fatalf("%s: final error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestVerifyingReaderOnlyAtEOF(t *testing.T) {
+	r := NewVerifyingReader(bytes.NewReader([]byte("abc")), [Size]byte{})
+	buf := make([]byte, 1)
+	for i := 0; i < 3; i++ {
+		if _, err := r.Read(buf); err != nil {
+			t.Fatalf("read %d: unexpected error %v before EOF", i, err)
+		}
+	}
+	if _, err := r.Read(buf); err != ErrDigestMismatch {
+		t.Fatalf("at EOF: got %v, want ErrDigestMismatch", err)
+	}
+}
+
+func TestVerifyingReaderPassesErrors(t *testing.T) {
+	r := NewVerifyingReader(errReader{io.ErrUnexpectedEOF}, [Size]byte{})
+	if _, err := r.Read(make([]byte, 1)); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestSumReaderContext(t *testing.T) {
+	data := testData(100000)
+	got, err := SumReaderContext(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("SumReaderContext: %v", err)
+	}
+	if want := Sum(data); got != want {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+}
+
+// cancelingReader is an endless reader that cancels its context after a
+// number of reads.
+type cancelingReader struct {
+	reads  int
+	cancel context.CancelFunc
+}
+
+func (r *cancelingReader) Read(p []byte) (int, error) {
+	if r.reads--; r.reads == 0 {
+		r.cancel()
+	}
+	return len(p), nil
+}
+
+func TestSumReaderContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sum, err := SumReaderContext(ctx, &cancelingReader{reads: 3, cancel: cancel})
+	if err != context.Canceled {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	if sum != ([Size]byte{}) {
+		t.Fatalf("got checksum %x after cancellation", sum)
+	}
+}
+
+func TestSumReaders(t *testing.T) {
+	a, b, c := testData(10), testData(100000), testData(64)
+	got, err := SumReaders(bytes.NewReader(a), bytes.NewReader(nil),
+		iotest.HalfReader(bytes.NewReader(b)), strings.NewReader(""), bytes.NewReader(c))
+	if err != nil {
+		t.Fatalf("SumReaders: %v", err)
+	}
+	joined := append(append(append([]byte(nil), a...), b...), c...)
+	if want := Sum(joined); got != want {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+
+	if got, _ := SumReaders(); got != EmptySum {
+		t.Fatalf("SumReaders() = %x, want %x", got, EmptySum)
+	}
+}
+
+func TestSumReadersError(t *testing.T) {
+	_, err := SumReaders(bytes.NewReader(testData(10)), iotest.TimeoutReader(bytes.NewReader(testData(1000))))
+	if err != iotest.ErrTimeout {
+		t.Fatalf("got error %v, want %v", err, iotest.ErrTimeout)
+	}
+}
+
+func TestSumReaderLen(t *testing.T) {
+	data := testData(100003)
+	for name, r := range map[string]io.Reader{
+		"plain":   bytes.NewReader(data),
+		"dataerr": iotest.DataErrReader(bytes.NewReader(data)),
+		"onebyte": iotest.OneByteReader(bytes.NewReader(data)),
+	} {
+		sum, n, err := SumReaderLen(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if n != int64(len(data)) {
+			t.Fatalf("%s: n = %d, want %d", name, n, len(data))
+		}
+		if want := Sum(data); sum != want {
+			t.Fatalf("%s: got %x, want %x", name, sum, want)
+		}
+	}
+}
+
+func TestCopyHash(t *testing.T) {
+	data := testData(100003)
+	var dst bytes.Buffer
+	sum, n, err := CopyHash(&dst, iotest.HalfReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) || !bytes.Equal(dst.Bytes(), data) {
+		t.Fatalf("copied %d bytes, want %d", n, len(data))
+	}
+	if want := Sum(data); sum != want {
+		t.Fatalf("\ngot : %x \nwant: %x", sum, want)
+	}
+
+	if _, _, err := CopyHash(&dst, errReader{io.ErrUnexpectedEOF}); err != io.ErrUnexpectedEOF {
+		t.Fatalf("read error: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	w := &limitedWriter{n: 10}
+	if _, n, err := CopyHash(w, bytes.NewReader(data)); err != io.ErrShortWrite || n != 10 {
+		t.Fatalf("write error: got %d, %v; want 10, %v", n, err, io.ErrShortWrite)
+	}
+}
+
+// limitedWriter accepts n bytes and then fails with io.ErrShortWrite.
+type limitedWriter struct{ n int }
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, io.ErrShortWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestSumChan(t *testing.T) {
+	chunks := [][]byte{[]byte("first"), nil, {}, testData(1000), []byte("last")}
+	ch := make(chan []byte)
+	go func() {
+		for _, c := range chunks {
+			ch <- c
+		}
+		close(ch)
+	}()
+	got := SumChan(ch)
+	if want := Sum(bytes.Join(chunks, nil)); got != want {
+		t.Fatalf("\ngot : %x \nwant: %x", got, want)
+	}
+
+	empty := make(chan []byte)
+	close(empty)
+	if got := SumChan(empty); got != EmptySum {
+		t.Fatalf("closed channel \ngot : %x \nwant: %x", got, EmptySum)
+	}
+}
+
+func TestSumRegion(t *testing.T) {
+	data := testData(100003)
+	r := bytes.NewReader(data)
+	for _, tt := range []struct{ off, length int64 }{
+		{0, 0},
+		{0, int64(len(data))},
+		{1, 63},
+		{12345, 54321},
+		{int64(len(data)) - 1, 1},
+		{int64(len(data)), 0},
+	} {
+		got, err := SumRegion(r, tt.off, tt.length)
+		if err != nil {
+			t.Fatalf("SumRegion(%d, %d): %v", tt.off, tt.length, err)
+		}
+		if want := Sum(data[tt.off : tt.off+tt.length]); got != want {
+			t.Fatalf("SumRegion(%d, %d) \ngot : %x \nwant: %x", tt.off, tt.length, got, want)
+		}
+	}
+	if _, err := SumRegion(r, 100000, 4); err != io.ErrUnexpectedEOF {
+		t.Fatalf("region past the end: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := SumRegion(r, -1, 4); !errors.Is(err, ErrInvalidLength) {
+		t.Fatalf("negative offset: got %v, want %v", err, ErrInvalidLength)
+	}
+}
+
+func TestMeteredReader(t *testing.T) {
+	data := testData(10000)
+	for _, limit := range []int64{10000, 10001, 1 << 20, math.MaxInt64} {
+		r := NewMeteredReader(iotest.HalfReader(bytes.NewReader(data)), limit)
+		got, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("limit %d: %v", limit, err)
+		}
+		if !bytes.Equal(got, data) || r.Count() != int64(len(data)) {
+			t.Fatalf("limit %d: read %d bytes, Count = %d, want %d", limit, len(got), r.Count(), len(data))
+		}
+		if want := Sum(data); r.Sum() != want {
+			t.Fatalf("limit %d \ngot : %x \nwant: %x", limit, r.Sum(), want)
+		}
+	}
+
+	for _, limit := range []int64{0, 1, 9999} {
+		r := NewMeteredReader(bytes.NewReader(data), limit)
+		got, err := ioutil.ReadAll(r)
+		if err != ErrLimitExceeded {
+			t.Fatalf("limit %d: got %v, want %v", limit, err, ErrLimitExceeded)
+		}
+		if int64(len(got)) != limit || r.Count() != limit {
+			t.Fatalf("limit %d: read %d bytes, Count = %d", limit, len(got), r.Count())
+		}
+		if want := Sum(data[:limit]); r.Sum() != want {
+			t.Fatalf("limit %d: Sum covers more than the bytes returned", limit)
+		}
+	}
+
+	// Once the limit is exceeded, reads fail without consuming more input.
+	br := bytes.NewReader(data)
+	r := NewMeteredReader(br, 10)
+	ioutil.ReadAll(r)
+	rest := br.Len()
+	for i := 0; i < 3; i++ {
+		if n, err := r.Read(make([]byte, 10)); n != 0 || err != ErrLimitExceeded {
+			t.Fatalf("read %d after the limit: got %d, %v", i, n, err)
+		}
+	}
+	if br.Len() != rest || rest != len(data)-11 {
+		t.Fatalf("underlying reader has %d bytes left, want %d", br.Len(), len(data)-11)
+	}
+}
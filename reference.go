@@ -0,0 +1,103 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// SumReference returns the SM3 checksum of data computed by a literal,
+// unoptimized transcription of GM/T 0004-2012 that shares no code with
+// Sum. It is meant for differential testing and always agrees with Sum.
+func SumReference(data []byte) [Size]byte {
+	// Padding: 0x80, zeros to 56 mod 64 bytes, then the bit length.
+	m := append([]byte(nil), data...)
+	m = append(m, 0x80)
+	for len(m)%BlockSize != BlockSize-8 {
+		m = append(m, 0)
+	}
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(data))<<3)
+	m = append(m, l[:]...)
+
+	v := [8]uint32{
+		0x7380166f, 0x4914b2b9, 0x172442d7, 0xda8a0600,
+		0xa96f30bc, 0x163138aa, 0xe38dee4d, 0xb0fb0e4e,
+	}
+	for ; len(m) > 0; m = m[BlockSize:] {
+		v = refCompress(v, m[:BlockSize])
+	}
+
+	var sum [Size]byte
+	for i, x := range v {
+		binary.BigEndian.PutUint32(sum[4*i:], x)
+	}
+	return sum
+}
+
+// refCompress is the compression function CF of the standard, one round
+// per loop iteration.
+func refCompress(v [8]uint32, b []byte) [8]uint32 {
+	var w [68]uint32
+	var w1 [64]uint32
+	for j := 0; j < 16; j++ {
+		w[j] = binary.BigEndian.Uint32(b[4*j:])
+	}
+	for j := 16; j < 68; j++ {
+		x := w[j-16] ^ w[j-9] ^ bits.RotateLeft32(w[j-3], 15)
+		w[j] = refP1(x) ^ bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+	}
+	for j := 0; j < 64; j++ {
+		w1[j] = w[j] ^ w[j+4]
+	}
+
+	a, b2, c, d, e, f, g, h := v[0], v[1], v[2], v[3], v[4], v[5], v[6], v[7]
+	for j := 0; j < 64; j++ {
+		t := uint32(0x79cc4519)
+		if j >= 16 {
+			t = 0x7a879d8a
+		}
+		ss1 := bits.RotateLeft32(bits.RotateLeft32(a, 12)+e+bits.RotateLeft32(t, j%32), 7)
+		ss2 := ss1 ^ bits.RotateLeft32(a, 12)
+		tt1 := refFF(j, a, b2, c) + d + ss2 + w1[j]
+		tt2 := refGG(j, e, f, g) + h + ss1 + w[j]
+		d = c
+		c = bits.RotateLeft32(b2, 9)
+		b2 = a
+		a = tt1
+		h = g
+		g = bits.RotateLeft32(f, 19)
+		f = e
+		e = refP0(tt2)
+	}
+
+	return [8]uint32{
+		a ^ v[0], b2 ^ v[1], c ^ v[2], d ^ v[3],
+		e ^ v[4], f ^ v[5], g ^ v[6], h ^ v[7],
+	}
+}
+
+func refFF(j int, x, y, z uint32) uint32 {
+	if j < 16 {
+		return x ^ y ^ z
+	}
+	return (x & y) | (x & z) | (y & z)
+}
+
+func refGG(j int, x, y, z uint32) uint32 {
+	if j < 16 {
+		return x ^ y ^ z
+	}
+	return (x & y) | (^x & z)
+}
+
+func refP0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+func refP1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
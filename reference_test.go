@@ -0,0 +1,34 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// goldenInput returns the message of g, hex decoding it if needed.
+func goldenInput(g sm3Test) []byte {
+	if g.hex {
+		x, _ := hex.DecodeString(g.in)
+		return x
+	}
+	return []byte(g.in)
+}
+
+func TestSumReference(t *testing.T) {
+	for _, g := range golden {
+		if s := fmt.Sprintf("%x", SumReference(goldenInput(g))); s != g.out {
+			t.Fatalf("SumReference(%q) \ngot : %s \nwant: %s", g.in, s, g.out)
+		}
+	}
+	for n := 0; n <= 4*BlockSize; n++ {
+		data := testData(n)
+		if got, want := Sum(data), SumReference(data); got != want {
+			t.Fatalf("len %d: Sum = %x, SumReference = %x", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bufio"
+	"io"
+)
+
+// A ScanHasher reads lines with a bufio.Scanner and hashes each line as
+// well as the whole input.
+type ScanHasher struct {
+	s     *bufio.Scanner
+	total digest
+}
+
+// NewScanHasher returns a ScanHasher reading lines from r.
+func NewScanHasher(r io.Reader) *ScanHasher {
+	h := &ScanHasher{s: bufio.NewScanner(r)}
+	h.total.Reset()
+	return h
+}
+
+// Next returns the next line, without its end-of-line marker, and the SM3
+// checksum of that line. ok is false at the end of the input or on error;
+// see Err. The returned line is only valid until the next call.
+func (h *ScanHasher) Next() (line []byte, sum [Size]byte, ok bool) {
+	if !h.s.Scan() {
+		return nil, [Size]byte{}, false
+	}
+	line = h.s.Bytes()
+	h.total.Write(line)
+	h.total.Write([]byte{'\n'})
+	return line, Sum(line), true
+}
+
+// Total returns the SM3 checksum of the lines returned so far, each
+// followed by "\n". The last line gets a "\n" whether or not the input
+// ended with one, and "\r\n" line endings count as "\n", so for input that
+// uses "\n" and ends with it Total is the checksum of the input itself.
+func (h *ScanHasher) Total() [Size]byte {
+	return h.total.checkSum()
+}
+
+// Err returns the first non-EOF error encountered while reading.
+func (h *ScanHasher) Err() error {
+	return h.s.Err()
+}
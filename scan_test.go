@@ -0,0 +1,45 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanHasher(t *testing.T) {
+	const input = "first line\nsecond\n\nlast line"
+	h := NewScanHasher(strings.NewReader(input))
+	var lines []string
+	for {
+		line, sum, ok := h.Next()
+		if !ok {
+			break
+		}
+		if want := Sum(line); sum != want {
+			t.Fatalf("line %q: digest %x, want %x", line, sum, want)
+		}
+		lines = append(lines, string(line))
+	}
+	if err := h.Err(); err != nil {
+		t.Fatalf("Err: %v", err)
+	}
+	if want := []string{"first line", "second", "", "last line"}; strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines = %q, want %q", lines, want)
+	}
+	if got, want := h.Total(), Sum([]byte(input+"\n")); got != want {
+		t.Fatalf("Total = %x, want %x", got, want)
+	}
+
+	// With a trailing newline, and with CRLF endings, Total is the same.
+	for _, in := range []string{input + "\n", strings.Replace(input, "\n", "\r\n", -1)} {
+		h := NewScanHasher(strings.NewReader(in))
+		for _, _, ok := h.Next(); ok; _, _, ok = h.Next() {
+		}
+		if got, want := h.Total(), Sum([]byte(input+"\n")); got != want {
+			t.Fatalf("%q: Total = %x, want %x", in, got, want)
+		}
+	}
+}
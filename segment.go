@@ -0,0 +1,63 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+// A SegmentHasher splits the data written to it into fixed-size segments
+// and computes the SM3 checksum of each one separately.
+type SegmentHasher struct {
+	size    int
+	n       int // bytes of the current segment written so far
+	d       digest
+	digests [][Size]byte
+}
+
+// NewSegmentHasher returns a SegmentHasher for segments of segmentSize
+// bytes. It panics if segmentSize is not positive.
+func NewSegmentHasher(segmentSize int) *SegmentHasher {
+	if segmentSize <= 0 {
+		panic(lengthError("segment size must be positive"))
+	}
+	s := &SegmentHasher{size: segmentSize}
+	s.d.Reset()
+	return s
+}
+
+// Write hashes p, completing segments as their last byte arrives. It never
+// returns an error.
+func (s *SegmentHasher) Write(p []byte) (int, error) {
+	nn := len(p)
+	for len(p) > 0 {
+		n := s.size - s.n
+		if n > len(p) {
+			n = len(p)
+		}
+		s.d.Write(p[:n])
+		s.n += n
+		p = p[n:]
+		if s.n == s.size {
+			s.flush()
+		}
+	}
+	return nn, nil
+}
+
+// Finish completes the final, partial segment, if any.
+func (s *SegmentHasher) Finish() {
+	if s.n > 0 {
+		s.flush()
+	}
+}
+
+func (s *SegmentHasher) flush() {
+	s.digests = append(s.digests, s.d.checkSum())
+	s.d.Reset()
+	s.n = 0
+}
+
+// Digests returns the checksums of the segments completed so far, in
+// order. A trailing partial segment is only included after Finish.
+func (s *SegmentHasher) Digests() [][Size]byte {
+	return s.digests
+}
@@ -0,0 +1,50 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSegmentHasher(t *testing.T) {
+	const size = 1000
+	for _, n := range []int{0, 1, size - 1, size, 3 * size, 3*size + 17} {
+		data := testData(n)
+		s := NewSegmentHasher(size)
+		io.Copy(s, iotest.HalfReader(bytes.NewReader(data)))
+		s.Finish()
+
+		want := (n + size - 1) / size
+		got := s.Digests()
+		if len(got) != want {
+			t.Fatalf("%d bytes: got %d digests, want %d", n, len(got), want)
+		}
+		for i := range got {
+			end := (i + 1) * size
+			if end > n {
+				end = n
+			}
+			if sum := Sum(data[i*size : end]); got[i] != sum {
+				t.Fatalf("%d bytes: segment %d = %x, want %x", n, i, got[i], sum)
+			}
+		}
+	}
+}
+
+func TestSegmentHasherPartial(t *testing.T) {
+	s := NewSegmentHasher(10)
+	s.Write(testData(25))
+	if n := len(s.Digests()); n != 2 {
+		t.Fatalf("before Finish: got %d digests, want 2", n)
+	}
+	s.Finish()
+	s.Finish()
+	if n := len(s.Digests()); n != 3 {
+		t.Fatalf("after Finish: got %d digests, want 3", n)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// SelfTest checks the hash, through both the block function in use and the
+// portable one, and HMAC-SM3 against built-in known answers. It returns an
+// error naming the first check that fails, which indicates a miscompiled or
+// corrupted binary.
+func SelfTest() error {
+	sum := Sum([]byte("abc"))
+	if hex.EncodeToString(sum[:]) != VectorABC {
+		return errors.New("sm3: self-test of SM3(\"abc\") failed")
+	}
+
+	if !checkBlock() {
+		return errors.New("sm3: self-test of block against blockGeneric failed")
+	}
+
+	// RFC 4231 test case 1 inputs, SM3 output.
+	const mac = "51b00d1fb49832bfb01c3ce27848e59f871d9ba938dc563b338ca964755cce70"
+	key := []byte{
+		0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b,
+		0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b, 0x0b,
+	}
+	sum = SumHMAC(key, []byte("Hi There"))
+	if hex.EncodeToString(sum[:]) != mac {
+		return errors.New("sm3: self-test of HMAC-SM3 failed")
+	}
+	return nil
+}
+
+// checkBlock reports whether the block function in use agrees with
+// blockGeneric on the padded message "abc" followed by three blocks of a
+// fixed byte pattern.
+func checkBlock() bool {
+	var p [4 * BlockSize]byte
+	copy(p[:], "abc\x80")
+	p[BlockSize-1] = 3 * 8
+	for i := BlockSize; i < len(p); i++ {
+		p[i] = byte(i*7 + 1)
+	}
+	var d, g digest
+	d.Reset()
+	g.Reset()
+	block(&d, p[:])
+	blockGeneric(&g, p[:])
+	return d.h == g.h
+}
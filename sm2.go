@@ -0,0 +1,66 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"hash"
+	"math"
+	"math/big"
+)
+
+// sm2CoordSize is the size in bytes of an SM2 field element.
+const sm2CoordSize = 32
+
+// ComputeZA returns the SM2 user identity hash of GM/T 0003.2
+//
+//	ZA = SM3(ENTL || ID || a || b || Gx || Gy || Px || Py)
+//
+// where ENTL is the bit length of id as a 2-byte big-endian integer, a, b,
+// Gx and Gy are the curve parameters and Px, Py the public key. Each of
+// the six field elements must be exactly 32 bytes.
+func ComputeZA(id []byte, a, b, gx, gy, px, py []byte) ([Size]byte, error) {
+	if uint64(len(id))*8 > math.MaxUint16 {
+		return [Size]byte{}, lengthError("SM2 user ID too long")
+	}
+	for _, v := range [][]byte{a, b, gx, gy, px, py} {
+		if len(v) != sm2CoordSize {
+			return [Size]byte{}, lengthError("SM2 field element must be 32 bytes")
+		}
+	}
+
+	var d digest
+	d.Reset()
+	entl := len(id) * 8
+	d.Write([]byte{byte(entl >> 8), byte(entl)})
+	d.Write(id)
+	for _, v := range [][]byte{a, b, gx, gy, px, py} {
+		d.Write(v)
+	}
+	return d.checkSum(), nil
+}
+
+// SumForSM2 returns the SM2 signature digest e = SM3(ZA || M) of GM/T
+// 0003.2 for message under the identity hash za from ComputeZA.
+func SumForSM2(za [Size]byte, message []byte) [Size]byte {
+	var d digest
+	d.Reset()
+	d.Write(za[:])
+	d.Write(message)
+	return d.checkSum()
+}
+
+// NewSM2Digest returns a new hash.Hash computing the SM2 signature digest
+// e = SM3(ZA || M) of the message M written to it, for messages too large
+// to pass to SumForSM2. Reset returns it to the state right after ZA.
+func NewSM2Digest(za [Size]byte) hash.Hash {
+	return NewWithPrefix(za[:])
+}
+
+// SumBigInt returns the SM3 checksum of data as a big-endian unsigned
+// integer, the way SM2 interprets the message digest e.
+func SumBigInt(data []byte) *big.Int {
+	sum := Sum(data)
+	return new(big.Int).SetBytes(sum[:])
+}
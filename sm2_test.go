@@ -0,0 +1,103 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"testing"
+)
+
+func decodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// SM2 recommended curve and the signature example key of GM/T 0003.5.
+var (
+	sm2A  = decodeHex("fffffffeffffffffffffffffffffffffffffffff00000000fffffffffffffffc")
+	sm2B  = decodeHex("28e9fa9e9d9f5e344d5a9e4bcf6509a7f39789f515ab8f92ddbcbd414d940e93")
+	sm2Gx = decodeHex("32c4ae2c1f1981195f9904466a39c9948fe30bbff2660be1715a4589334c74c7")
+	sm2Gy = decodeHex("bc3736a2f4f6779c59bdcee36b692153d0a9877cc62a474002df32e52139f0a0")
+	sm2Px = decodeHex("09f9df311e5421a150dd7d161e4bc5c672179fad1833fc076bb08ff356f35020")
+	sm2Py = decodeHex("ccea490ce26775a52dc6ea718cc1aa600aed05fbf35e084a6632f6072da9ad13")
+
+	sm2ID = []byte("1234567812345678")
+	sm2ZA = "b2e14c5c79c6df5b85f4fe7ed8db7a262b9da7e07ccb0ea9f4747b8ccda8a4f3"
+)
+
+func TestComputeZA(t *testing.T) {
+	za, err := ComputeZA(sm2ID, sm2A, sm2B, sm2Gx, sm2Gy, sm2Px, sm2Py)
+	if err != nil {
+		t.Fatalf("ComputeZA: %v", err)
+	}
+	if s := hex.EncodeToString(za[:]); s != sm2ZA {
+		t.Fatalf("ComputeZA \ngot : %s \nwant: %s", s, sm2ZA)
+	}
+}
+
+func TestSumForSM2(t *testing.T) {
+	za, _ := ComputeZA(sm2ID, sm2A, sm2B, sm2Gx, sm2Gy, sm2Px, sm2Py)
+	e := SumForSM2(za, []byte("message digest"))
+	const want = "f0b43e94ba45accaace692ed534382eb17e6ab5a19ce7b31f4486fdfc0d28640"
+	if s := hex.EncodeToString(e[:]); s != want {
+		t.Fatalf("SumForSM2 \ngot : %s \nwant: %s", s, want)
+	}
+	msg := []byte("message digest")
+	if n := testing.AllocsPerRun(10, func() { SumForSM2(za, msg) }); n > 0 {
+		t.Fatalf("SumForSM2 allocates %v times, want 0", n)
+	}
+}
+
+func TestNewSM2Digest(t *testing.T) {
+	za, _ := ComputeZA(sm2ID, sm2A, sm2B, sm2Gx, sm2Gy, sm2Px, sm2Py)
+	msg := testData(100003)
+	want := SumForSM2(za, msg)
+	h := NewSM2Digest(za)
+	for j := 0; j < 2; j++ {
+		if _, err := io.CopyBuffer(h, bytes.NewReader(msg), make([]byte, 1000)); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("pass %d \ngot : %x \nwant: %x", j, got, want)
+		}
+		h.Reset()
+	}
+}
+
+func TestSumBigInt(t *testing.T) {
+	want, _ := new(big.Int).SetString("66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0", 16)
+	if e := SumBigInt([]byte("abc")); e.Cmp(want) != 0 {
+		t.Fatalf("\ngot : %x \nwant: %x", e, want)
+	}
+
+	// The digest of "133" starts with a zero byte, which Bytes drops.
+	for _, in := range []string{"abc", "133"} {
+		e := SumBigInt([]byte(in))
+		var got [Size]byte
+		b := e.Bytes()
+		copy(got[Size-len(b):], b)
+		if sum := Sum([]byte(in)); got != sum {
+			t.Fatalf("%q \ngot : %x \nwant: %x", in, got, sum)
+		}
+	}
+}
+
+func TestComputeZAInvalid(t *testing.T) {
+	if _, err := ComputeZA(make([]byte, 8192), sm2A, sm2B, sm2Gx, sm2Gy, sm2Px, sm2Py); err == nil {
+		t.Fatal("ComputeZA accepted an ID longer than 65535 bits")
+	}
+	if _, err := ComputeZA(make([]byte, 8191), sm2A, sm2B, sm2Gx, sm2Gy, sm2Px, sm2Py); err != nil {
+		t.Fatalf("ComputeZA rejected the longest ID: %v", err)
+	}
+	if _, err := ComputeZA(sm2ID, sm2A, sm2B, sm2Gx, sm2Gy, sm2Px[1:], sm2Py); err == nil {
+		t.Fatal("ComputeZA accepted a 31 byte coordinate")
+	}
+}
@@ -5,11 +5,21 @@
 // Package sm3 implements the SM3 hash algorithm.
 //
 // See https://tools.ietf.org/id/draft-oscca-cfrg-sm3-01.html
+//
+// On amd64 and arm64 the compression function is implemented in assembly.
+// Building with the purego tag selects the portable Go implementation on
+// every architecture, for targets where assembly cannot be used and to
+// test the Go code on machines that would otherwise use assembly.
+// Building with the sm3debug tag adds a check at initialization that
+// panics if the block function in use disagrees with the portable one.
+// Building with the sm3small tag selects a compact, slower Go
+// implementation for targets where binary size matters most.
 package sm3
 
 import (
 	"encoding/binary"
 	"hash"
+	"runtime"
 )
 
 const (
@@ -20,6 +30,10 @@ const (
 	BlockSize = 64
 
 	chunk = BlockSize
+
+	// maxLen is the longest input, in bytes, whose bit length fits in the
+	// 64-bit length field of the padding.
+	maxLen = 1<<61 - 1
 )
 
 var (
@@ -35,13 +49,40 @@ var (
 	}
 )
 
-// New returns a new hash.Hash computing the SM4 checksum.
+// EmptySum is the SM3 checksum of the empty message,
+// 1ab21d8355cfa17f8e61194831e81a8f22bec8c728fefb747ed035eb5082aa2b.
+var EmptySum = [Size]byte{
+	0x1a, 0xb2, 0x1d, 0x83, 0x55, 0xcf, 0xa1, 0x7f,
+	0x8e, 0x61, 0x19, 0x48, 0x31, 0xe8, 0x1a, 0x8f,
+	0x22, 0xbe, 0xc8, 0xc7, 0x28, 0xfe, 0xfb, 0x74,
+	0x7e, 0xd0, 0x35, 0xeb, 0x50, 0x82, 0xaa, 0x2b,
+}
+
+// New returns a new hash.Hash computing the SM3 checksum. It is not safe
+// for concurrent use by multiple goroutines; see NewConcurrent.
 func New() hash.Hash {
 	d := new(digest)
 	d.Reset()
 	return d
 }
 
+// NewWithState returns a new hash.Hash resuming from an intermediate SM3
+// state: the chaining value h, the input buffered since the last complete
+// block and the total number of bytes hashed so far. buffered must be
+// shorter than BlockSize and agree with total, that is
+// len(buffered) == total % BlockSize.
+func NewWithState(h [8]uint32, buffered []byte, total uint64) (hash.Hash, error) {
+	if len(buffered) >= BlockSize {
+		return nil, stateError("buffered input must be shorter than BlockSize")
+	}
+	if uint64(len(buffered)) != total%BlockSize {
+		return nil, stateError("buffered input inconsistent with total length")
+	}
+	d := &digest{h: h, len: total}
+	d.nx = copy(d.x[:], buffered)
+	return d, nil
+}
+
 type digest struct {
 	h   [8]uint32
 	x   [chunk]byte
@@ -49,6 +90,104 @@ type digest struct {
 	len uint64
 }
 
+// The marshaled state of a digest is a fixed marshaledSize byte layout,
+// all integers big-endian:
+//
+//	magic  [4]byte          "sm3\x01", the last byte is the format version
+//	h      [8]uint32        chaining value
+//	x      [BlockSize]byte  buffered input, only x[:nx] is significant
+//	nx     uint8            number of buffered bytes
+//	len    uint64           total number of bytes written
+//
+// The layout must stay stable so that states saved by one release can be
+// restored by another.
+const (
+	magic         = "sm3\x01"
+	marshaledSize = len(magic) + 8*4 + chunk + 1 + 8
+)
+
+func (d *digest) MarshalBinary() ([]byte, error) {
+	return d.AppendBinary(make([]byte, 0, marshaledSize))
+}
+
+// AppendBinary appends the marshaled state of d to b, implementing
+// encoding.BinaryAppender.
+func (d *digest) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, magic...)
+	for _, v := range d.h {
+		b = appendUint32(b, v)
+	}
+	b = append(b, d.x[:d.nx]...)
+	b = append(b, make([]byte, len(d.x)-d.nx)...)
+	b = append(b, byte(d.nx))
+	b = appendUint64(b, d.len)
+	return b, nil
+}
+
+func (d *digest) UnmarshalBinary(b []byte) error {
+	if len(b) < len(magic) || string(b[:len(magic)]) != magic {
+		return stateError("bad identifier")
+	}
+	if len(b) != marshaledSize {
+		return stateError("bad size")
+	}
+	nx := int(b[marshaledSize-9])
+	_, n := consumeUint64(b[marshaledSize-8:])
+	if nx >= chunk || uint64(nx) != n%chunk {
+		return stateError("bad buffer length")
+	}
+	b = b[len(magic):]
+	for i := range d.h {
+		b, d.h[i] = consumeUint32(b)
+	}
+	copy(d.x[:], b)
+	d.nx = nx
+	d.len = n
+	return nil
+}
+
+// Resume returns a new hash.Hash continuing from state, as produced by the
+// MarshalBinary method of a hash returned by New. It returns an error
+// wrapping ErrInvalidState if state is malformed.
+func Resume(state []byte) (hash.Hash, error) {
+	d := new(digest)
+	if err := d.UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GobEncode implements gob.GobEncoder using the MarshalBinary format.
+func (d *digest) GobEncode() ([]byte, error) {
+	return d.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder, validating the state like
+// UnmarshalBinary.
+func (d *digest) GobDecode(b []byte) error {
+	return d.UnmarshalBinary(b)
+}
+
+func appendUint32(b []byte, x uint32) []byte {
+	var a [4]byte
+	binary.BigEndian.PutUint32(a[:], x)
+	return append(b, a[:]...)
+}
+
+func appendUint64(b []byte, x uint64) []byte {
+	var a [8]byte
+	binary.BigEndian.PutUint64(a[:], x)
+	return append(b, a[:]...)
+}
+
+func consumeUint32(b []byte) ([]byte, uint32) {
+	return b[4:], binary.BigEndian.Uint32(b)
+}
+
+func consumeUint64(b []byte) ([]byte, uint64) {
+	return b[8:], binary.BigEndian.Uint64(b)
+}
+
 func (d *digest) Write(p []byte) (nn int, err error) {
 	nn = len(p)
 	d.len += uint64(nn)
@@ -73,18 +212,77 @@ func (d *digest) Write(p []byte) (nn int, err error) {
 	return
 }
 
-func (d0 *digest) Sum(b []byte) []byte {
-	d := *d0
+// WriteString is like Write but hashes s without converting it to a byte
+// slice first. Full blocks are staged through the internal buffer.
+func (d *digest) WriteString(s string) (nn int, err error) {
+	nn = len(s)
+	d.len += uint64(nn)
+	for len(s) > 0 {
+		n := copy(d.x[d.nx:], s)
+		d.nx += n
+		if d.nx == chunk {
+			block(d, d.x[:])
+			d.nx = 0
+		}
+		s = s[n:]
+	}
+	return
+}
+
+// WriteByte hashes the single byte c, implementing io.ByteWriter. It never
+// returns an error.
+func (d *digest) WriteByte(c byte) error {
+	d.len++
+	d.x[d.nx] = c
+	d.nx++
+	if d.nx == chunk {
+		block(d, d.x[:])
+		d.nx = 0
+	}
+	return nil
+}
+
+// Sum appends the checksum of the data written so far to b. It does not
+// finalize the hash: writes after Sum continue the same message, so each
+// Sum returns the checksum of everything written up to that point.
+func (d *digest) Sum(b []byte) []byte {
 	hash := d.checkSum()
 	return append(b, hash[:]...)
 }
 
+// SumReset appends the checksum of the data written so far to b, like Sum,
+// and then resets the hash for the next message.
+func (d *digest) SumReset(b []byte) []byte {
+	b = d.Sum(b)
+	d.Reset()
+	return b
+}
+
 func (d *digest) Reset() {
 	copy(d.h[:], iv[:])
 	d.nx = 0
 	d.len = 0
 }
 
+// Wipe overwrites the whole hash state, including buffered input, with
+// zeros. Unlike Reset it does not restore the initial value, so the digest
+// is unusable until it is Reset. The buffer is cleared through a slice of
+// the digest's own memory and d is kept alive past the stores, so the
+// compiler cannot treat them as dead.
+func (d *digest) Wipe() {
+	h := d.h[:]
+	for i := range h {
+		h[i] = 0
+	}
+	x := d.x[:]
+	for i := range x {
+		x[i] = 0
+	}
+	d.nx = 0
+	d.len = 0
+	runtime.KeepAlive(d)
+}
+
 func (d *digest) Size() int {
 	return Size
 }
@@ -93,44 +291,214 @@ func (d *digest) BlockSize() int {
 	return BlockSize
 }
 
+// Len returns the number of bytes written since the last Reset.
+func (d *digest) Len() uint64 {
+	return d.len
+}
+
+// State returns a copy of the chaining value. It reflects only complete
+// blocks, so it is the SM3 compression state of the input only when Len is
+// a multiple of BlockSize; buffered bytes of a partial block are not
+// included.
+func (d *digest) State() [8]uint32 {
+	return d.h
+}
+
+// Buffered returns the number of bytes written since the last complete
+// block, which are held until the block is filled.
+func (d *digest) Buffered() int {
+	return d.nx
+}
+
+// AtBlockBoundary reports whether the input so far is a whole number of
+// blocks, in which case State is the compression state of all of it.
+func (d *digest) AtBlockBoundary() bool {
+	return d.nx == 0
+}
+
+// Pending returns a copy of the bytes written since the last complete
+// block.
+func (d *digest) Pending() []byte {
+	return append([]byte(nil), d.x[:d.nx]...)
+}
+
+// Clone returns a copy of the hash state that can be written to
+// independently of d.
+func (d *digest) Clone() hash.Hash {
+	d0 := *d
+	return &d0
+}
+
+// Snapshot is an in-memory copy of a digest's state taken by Checkpoint.
+type Snapshot struct {
+	d digest
+}
+
+// Checkpoint captures the current hash state so that it can later be
+// rewound to with Restore.
+func (d *digest) Checkpoint() Snapshot {
+	return Snapshot{*d}
+}
+
+// Restore rewinds d to the state captured in s.
+func (d *digest) Restore(s Snapshot) {
+	*d = s.d
+}
+
 func (d *digest) checkSum() [Size]byte {
+	var digest [Size]byte
+	d.checkSumInto(&digest)
+	return digest
+}
+
+// checkSumInto writes the checksum of the data written to d to sum.
+// Only the chaining value is copied: the padded final block or blocks are
+// assembled on the stack from the buffered input, so d itself is left
+// unchanged.
+func (d *digest) checkSumInto(sum *[Size]byte) {
 	len := d.len
-	// padding method like crypto/sha1
-	var tmp [64]byte
-	tmp[0] = 0x80
-	if len%64 < 56 {
-		d.Write(tmp[0 : 56-len%64])
-	} else {
-		d.Write(tmp[0 : 64+56-len%64])
+	if len > maxLen {
+		panic(ErrInputTooLong)
 	}
 
-	// Length in bits.
-	len <<= 3
-	binary.BigEndian.PutUint64(tmp[:], len)
-	d.Write(tmp[0:8])
-
-	if d.nx != 0 {
-		panic("d.nx != 0")
+	// Padding: a single 1 bit, zeros up to 56 mod 64 bytes, and the length
+	// in bits.
+	var tmp [2 * chunk]byte
+	copy(tmp[:], d.x[:d.nx])
+	tmp[d.nx] = 0x80
+	n := chunk
+	if d.nx >= chunk-8 {
+		n = 2 * chunk
 	}
+	binary.BigEndian.PutUint64(tmp[n-8:], len<<3)
 
-	var digest [Size]byte
-
-	binary.BigEndian.PutUint32(digest[0:], d.h[0])
-	binary.BigEndian.PutUint32(digest[4:], d.h[1])
-	binary.BigEndian.PutUint32(digest[8:], d.h[2])
-	binary.BigEndian.PutUint32(digest[12:], d.h[3])
-	binary.BigEndian.PutUint32(digest[16:], d.h[4])
-	binary.BigEndian.PutUint32(digest[20:], d.h[5])
-	binary.BigEndian.PutUint32(digest[24:], d.h[6])
-	binary.BigEndian.PutUint32(digest[28:], d.h[7])
+	var f digest
+	f.h = d.h
+	block(&f, tmp[:n])
 
-	return digest
+	for i, v := range f.h {
+		binary.BigEndian.PutUint32(sum[4*i:], v)
+	}
 }
 
 // Sum returns the SM3 checksum of the data.
 func Sum(data []byte) [Size]byte {
+	var sum [Size]byte
+	sumInto(&sum, data)
+	return sum
+}
+
+// sumInto is the one-shot path of Sum and SumInto. Instead of buffering
+// through Write and finalizing a copy like checkSumInto, it hashes the
+// complete blocks of data in place and pads the tail once, so the block
+// buffer of the digest is never cleared or filled.
+func sumInto(sum *[Size]byte, data []byte) {
+	if uint64(len(data)) > maxLen {
+		panic(ErrInputTooLong)
+	}
+	var d digest
+	d.h = iv
+	full := len(data) &^ (chunk - 1)
+	if full > 0 {
+		block(&d, data[:full])
+	}
+
+	tail := data[full:]
+	var tmp [2 * chunk]byte
+	copy(tmp[:], tail)
+	tmp[len(tail)] = 0x80
+	n := chunk
+	if len(tail) >= chunk-8 {
+		n = 2 * chunk
+	}
+	binary.BigEndian.PutUint64(tmp[n-8:], uint64(len(data))<<3)
+	block(&d, tmp[:n])
+
+	for i, v := range d.h {
+		binary.BigEndian.PutUint32(sum[4*i:], v)
+	}
+}
+
+// SumAppend appends the SM3 checksum of data to dst and returns the
+// resulting slice.
+func SumAppend(dst, data []byte) []byte {
+	var d digest
+	d.Reset()
+	d.Write(data)
+	return d.Sum(dst)
+}
+
+// SumString returns the SM3 checksum of s without converting it to a byte
+// slice.
+func SumString(s string) [Size]byte {
+	var d digest
+	d.Reset()
+	d.WriteString(s)
+	return d.checkSum()
+}
+
+// SumInto computes the SM3 checksum of data directly into dst.
+func SumInto(dst *[Size]byte, data []byte) {
+	sumInto(dst, data)
+}
+
+// SumDouble returns SM3(SM3(data)).
+func SumDouble(data []byte) [Size]byte {
 	var d digest
 	d.Reset()
 	d.Write(data)
+	sum := d.checkSum()
+	d.Reset()
+	d.Write(sum[:])
+	return d.checkSum()
+}
+
+// SumSalted returns SM3(uint64(len(salt)) || salt || data), with the salt
+// length big-endian. The length prefix keeps different splits of the same
+// bytes into salt and data from colliding. This is a fast hash, not a
+// password hashing function; use PBKDF2 for passwords.
+func SumSalted(salt, data []byte) [Size]byte {
+	var d digest
+	d.Reset()
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(salt)))
+	d.Write(n[:])
+	d.Write(salt)
+	d.Write(data)
 	return d.checkSum()
 }
+
+// Sum64 returns the first 8 bytes of the SM3 checksum of data as a
+// big-endian uint64, for uses such as consistent sharding where a stable
+// integer is needed.
+func Sum64(data []byte) uint64 {
+	sum := Sum(data)
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// SumWithBlocks returns the SM3 checksum of data and the number of blocks
+// compressed to compute it, including the one or two blocks holding the
+// padding: (len(data)+8)/BlockSize + 1.
+func SumWithBlocks(data []byte) (sum [Size]byte, blocks int) {
+	return Sum(data), (len(data)+8)/BlockSize + 1
+}
+
+// SumHalves returns the SM3 checksum of data split into its most
+// significant half hi, the first 16 bytes, and least significant half lo,
+// the last 16 bytes, in the big-endian order of the checksum.
+func SumHalves(data []byte) (hi, lo [16]byte) {
+	sum := Sum(data)
+	copy(hi[:], sum[:16])
+	copy(lo[:], sum[16:])
+	return hi, lo
+}
+
+// SumTruncated returns the first n bytes of the SM3 checksum of data. n
+// must be between 1 and Size.
+func SumTruncated(data []byte, n int) ([]byte, error) {
+	if n < 1 || n > Size {
+		return nil, lengthError("truncated length out of range")
+	}
+	sum := Sum(data)
+	return sum[:n:n], nil
+}
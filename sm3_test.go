@@ -5,9 +5,13 @@
 package sm3
 
 import (
+	"bytes"
 	"crypto/rand"
+	"encoding"
+	"encoding/gob"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"strings"
 	"testing"
@@ -74,6 +78,696 @@ func TestGolden(t *testing.T) {
 	}
 }
 
+func TestEmptySum(t *testing.T) {
+	const want = "1ab21d8355cfa17f8e61194831e81a8f22bec8c728fefb747ed035eb5082aa2b"
+	if s := hex.EncodeToString(EmptySum[:]); s != want {
+		t.Fatalf("EmptySum \ngot : %s \nwant: %s", s, want)
+	}
+	if Sum(nil) != EmptySum || Sum([]byte{}) != EmptySum {
+		t.Fatal("Sum of nil or empty input differs from EmptySum")
+	}
+	h := New()
+	h.Write(nil)
+	if got := h.Sum(nil); !bytes.Equal(got, EmptySum[:]) {
+		t.Fatalf("New().Sum = %x, want %x", got, EmptySum)
+	}
+}
+
+// TestSumWordOrder checks that each chaining word lands big-endian at its
+// own offset of the checksum, starting from a state whose eight words are
+// all distinct and packing the expected bytes by hand.
+func TestSumWordOrder(t *testing.T) {
+	var d digest
+	d.h = [8]uint32{
+		0x00010203, 0x04050607, 0x08090a0b, 0x0c0d0e0f,
+		0x10111213, 0x14151617, 0x18191a1b, 0x1c1d1e1f,
+	}
+	sum := d.checkSum()
+
+	// With nothing buffered the padding is one block: 0x80, then zeros
+	// including the zero bit length.
+	var pad [BlockSize]byte
+	pad[0] = 0x80
+	f := d
+	block(&f, pad[:])
+	seen := make(map[uint32]bool)
+	var want [Size]byte
+	for i, v := range f.h {
+		seen[v] = true
+		want[4*i] = byte(v >> 24)
+		want[4*i+1] = byte(v >> 16)
+		want[4*i+2] = byte(v >> 8)
+		want[4*i+3] = byte(v)
+	}
+	if len(seen) != len(f.h) {
+		t.Fatalf("final state %08x has repeated words", f.h)
+	}
+	if sum != want {
+		t.Fatalf("checkSum \ngot : %x \nwant: %x", sum, want)
+	}
+}
+
+func TestGoldenMarshal(t *testing.T) {
+	for _, g := range golden {
+		in := g.in
+		if g.hex {
+			x, err := hex.DecodeString(g.in)
+			if err != nil {
+				t.Fatalf("decode failed:%s %s", g.in, err)
+			}
+			in = string(x)
+		}
+
+		h := New()
+		h2 := New()
+
+		io.WriteString(h, in[:len(in)/2])
+
+		state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatalf("could not marshal: %v", err)
+		}
+		if len(state) != marshaledSize {
+			t.Fatalf("marshaled state size: got %d, want %d", len(state), marshaledSize)
+		}
+
+		if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			t.Fatalf("could not unmarshal: %v", err)
+		}
+
+		io.WriteString(h, in[len(in)/2:])
+		io.WriteString(h2, in[len(in)/2:])
+
+		if actual, actual2 := h.Sum(nil), h2.Sum(nil); !bytes.Equal(actual, actual2) {
+			t.Fatalf("sm3(%q) = 0x%x != marshaled 0x%x", in, actual, actual2)
+		}
+	}
+}
+
+func TestMarshalLongMessage(t *testing.T) {
+	msg := make([]byte, 1000)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	for n := 0; n <= len(msg); n += 37 {
+		h := New()
+		h.Write(msg[:n])
+		state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+		h2 := New()
+		if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+			t.Fatalf("could not unmarshal after %d bytes: %v", n, err)
+		}
+		h2.Write(msg[n:])
+
+		want := Sum(msg)
+		if got := h2.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("split at %d: got %x, want %x", n, got, want)
+		}
+	}
+}
+
+func TestAppendBinary(t *testing.T) {
+	h := New()
+	io.WriteString(h, "hello, world")
+	want, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	prefix := []byte("prefix")
+	b, err := h.(interface {
+		AppendBinary([]byte) ([]byte, error)
+	}).AppendBinary(append([]byte(nil), prefix...))
+	if err != nil {
+		t.Fatalf("AppendBinary: %v", err)
+	}
+	if !bytes.HasPrefix(b, prefix) || !bytes.Equal(b[len(prefix):], want) {
+		t.Fatalf("AppendBinary \ngot : %x \nwant: %x%x", b, prefix, want)
+	}
+
+	h2 := New()
+	if err := h2.(encoding.BinaryUnmarshaler).UnmarshalBinary(b[len(prefix):]); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	io.WriteString(h, "!")
+	io.WriteString(h2, "!")
+	if got, want := h2.Sum(nil), h.Sum(nil); !bytes.Equal(got, want) {
+		t.Fatalf("after round trip: got %x, want %x", got, want)
+	}
+}
+
+func TestGob(t *testing.T) {
+	type job struct {
+		ID    int
+		State *digest
+	}
+	msg := []byte(strings.Repeat("distributed ", 20))
+	h := New().(*digest)
+	h.Write(msg[:100])
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(job{1, h}); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var j job
+	if err := gob.NewDecoder(&buf).Decode(&j); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	j.State.Write(msg[100:])
+	want := Sum(msg)
+	if got := j.State.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+
+	if err := new(digest).GobDecode([]byte("sm3\x01 too short")); err == nil {
+		t.Fatal("GobDecode accepted an invalid state")
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	h := New()
+	h.Write([]byte("abc"))
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+	badMagic := append([]byte(nil), state...)
+	badMagic[0] ^= 0xff
+	badVersion := append([]byte(nil), state...)
+	badVersion[len(magic)-1]++
+	badNx := append([]byte(nil), state...)
+	badNx[marshaledSize-9] = chunk
+	badLen := append([]byte(nil), state...)
+	badLen[marshaledSize-9] = 4
+
+	for _, tt := range []struct {
+		name  string
+		state []byte
+	}{
+		{"empty", nil},
+		{"magic", badMagic},
+		{"version", badVersion},
+		{"short", state[:len(state)-1]},
+		{"long", append(state, 0)},
+		{"nx", badNx},
+		{"nx-len", badLen},
+	} {
+		if err := New().(encoding.BinaryUnmarshaler).UnmarshalBinary(tt.state); err == nil {
+			t.Errorf("%s: UnmarshalBinary succeeded on invalid state", tt.name)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	header := strings.Repeat("common header ", 10)
+	h := New()
+	io.WriteString(h, header)
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tail := range []string{"", "a", strings.Repeat("tail", 40)} {
+		r, err := Resume(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(r, tail)
+		if got, want := r.Sum(nil), Sum([]byte(header+tail)); !bytes.Equal(got, want[:]) {
+			t.Fatalf("tail %q \ngot : %x \nwant: %x", tail, got, want)
+		}
+	}
+	if _, err := Resume(state[:10]); err == nil {
+		t.Fatal("Resume accepted a truncated state")
+	}
+}
+
+func TestSumNonDestructive(t *testing.T) {
+	parts := []string{"a", "bc", strings.Repeat("d", 70), "", strings.Repeat("e", 64)}
+	h := New()
+	var all string
+	for _, p := range parts {
+		io.WriteString(h, p)
+		all += p
+		want := Sum([]byte(all))
+		for j := 0; j < 2; j++ {
+			if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+				t.Fatalf("after %q, Sum #%d \ngot : %x \nwant: %x", all, j, got, want)
+			}
+		}
+	}
+}
+
+func TestCloneSumIndependent(t *testing.T) {
+	h := New().(*digest)
+	io.WriteString(h, "parent")
+	c := h.Clone()
+	for i := 0; i < 3; i++ {
+		io.WriteString(c, "child")
+		c.Sum(nil)
+	}
+	io.WriteString(h, " continues")
+	if got, want := h.Sum(nil), Sum([]byte("parent continues")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("parent after clone Sums \ngot : %x \nwant: %x", got, want)
+	}
+}
+
+func TestClone(t *testing.T) {
+	prefix := strings.Repeat("shared prefix ", 10)
+	h := New()
+	io.WriteString(h, prefix)
+
+	c := h.(interface {
+		Clone() hash.Hash
+	}).Clone()
+
+	io.WriteString(h, "original")
+	io.WriteString(c, "clone")
+
+	want1 := Sum([]byte(prefix + "original"))
+	want2 := Sum([]byte(prefix + "clone"))
+	if got := h.Sum(nil); !bytes.Equal(got, want1[:]) {
+		t.Fatalf("original: got %x, want %x", got, want1)
+	}
+	if got := c.Sum(nil); !bytes.Equal(got, want2[:]) {
+		t.Fatalf("clone: got %x, want %x", got, want2)
+	}
+}
+
+func TestWriteString(t *testing.T) {
+	msg := strings.Repeat("0123456789abcdef", 20)
+	for _, split := range []int{0, 1, 7, 63, 64, 65, 128, 200, len(msg)} {
+		h := New()
+		sw := h.(io.StringWriter)
+		n1, _ := sw.WriteString(msg[:split])
+		n2, _ := sw.WriteString(msg[split:])
+		if n1+n2 != len(msg) {
+			t.Fatalf("split %d: wrote %d bytes, want %d", split, n1+n2, len(msg))
+		}
+		want := Sum([]byte(msg))
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("split %d: got %x, want %x", split, got, want)
+		}
+	}
+}
+
+func TestWriteByte(t *testing.T) {
+	msg := testData(300)
+	h := New()
+	h.Write(msg[:10])
+	bw := h.(io.ByteWriter)
+	for _, c := range msg[10:] {
+		if err := bw.WriteByte(c); err != nil {
+			t.Fatalf("WriteByte: %v", err)
+		}
+	}
+	want := Sum(msg)
+	if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+	if n := h.(*digest).Len(); n != uint64(len(msg)) {
+		t.Fatalf("Len = %d, want %d", n, len(msg))
+	}
+}
+
+func TestWriteStringAllocs(t *testing.T) {
+	h := New()
+	sw := h.(io.StringWriter)
+	s := strings.Repeat("x", 1000)
+	if n := testing.AllocsPerRun(100, func() { sw.WriteString(s) }); n > 0 {
+		t.Fatalf("WriteString allocates %v times, want 0", n)
+	}
+}
+
+func TestLen(t *testing.T) {
+	d := New().(*digest)
+	var total uint64
+	for _, n := range []int{0, 1, 63, 64, 100, 1000} {
+		d.Write(make([]byte, n))
+		total += uint64(n)
+		if got := d.Len(); got != total {
+			t.Fatalf("after writing %d more bytes: Len = %d, want %d", n, got, total)
+		}
+	}
+	d.Sum(nil)
+	if got := d.Len(); got != total {
+		t.Fatalf("after Sum: Len = %d, want %d", got, total)
+	}
+	d.Reset()
+	if got := d.Len(); got != 0 {
+		t.Fatalf("after Reset: Len = %d, want 0", got)
+	}
+}
+
+func TestInputTooLong(t *testing.T) {
+	d := New().(*digest)
+	d.len = maxLen - maxLen%chunk
+	d.Sum(nil)
+
+	d.len = maxLen + 1
+	defer func() {
+		if r := recover(); r != ErrInputTooLong {
+			t.Fatalf("Sum after %d bytes panicked with %v, want %v", uint64(maxLen+1), r, ErrInputTooLong)
+		}
+	}()
+	d.Sum(nil)
+}
+
+func TestBuffered(t *testing.T) {
+	d := New().(*digest)
+	if d.Buffered() != 0 || len(d.Pending()) != 0 {
+		t.Fatalf("new digest: Buffered = %d, Pending = %q", d.Buffered(), d.Pending())
+	}
+	msg := []byte(strings.Repeat("0123456789", 13))
+	d.Write(msg)
+	tail := msg[len(msg)-len(msg)%BlockSize:]
+	if got := d.Buffered(); got != len(tail) {
+		t.Fatalf("Buffered = %d, want %d", got, len(tail))
+	}
+	p := d.Pending()
+	if !bytes.Equal(p, tail) {
+		t.Fatalf("Pending \ngot : %q \nwant: %q", p, tail)
+	}
+	p[0] ^= 0xff
+	if !bytes.Equal(d.Pending(), tail) {
+		t.Fatal("modifying the result of Pending changed the digest")
+	}
+	d.Write(make([]byte, BlockSize-len(tail)))
+	if d.Buffered() != 0 {
+		t.Fatalf("after completing the block: Buffered = %d, want 0", d.Buffered())
+	}
+}
+
+func TestAtBlockBoundary(t *testing.T) {
+	d := New().(*digest)
+	if !d.AtBlockBoundary() {
+		t.Fatal("new digest is not at a block boundary")
+	}
+	for n := 1; n <= 2*BlockSize+1; n++ {
+		d.WriteByte(0)
+		if want := n%BlockSize == 0; d.AtBlockBoundary() != want {
+			t.Fatalf("after %d bytes: AtBlockBoundary = %v, want %v", n, !want, want)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	d := New().(*digest)
+	if got := d.State(); got != iv {
+		t.Fatalf("initial State = %08x, want %08x", got, iv)
+	}
+
+	// Chaining value after the first block of example 2, computed with an
+	// independent implementation of the compression function.
+	want := [8]uint32{
+		0x5950de81, 0x468664eb, 0x42fd4c86, 0x1e7ca00a,
+		0xc0a5910b, 0xae9a55ea, 0x1adb8d17, 0x763ca222,
+	}
+	io.WriteString(d, strings.Repeat("abcd", 16))
+	if got := d.State(); got != want {
+		t.Fatalf("State \ngot : %08x \nwant: %08x", got, want)
+	}
+
+	s := d.State()
+	s[0] = 0
+	if d.State() != want {
+		t.Fatal("modifying the result of State changed the digest")
+	}
+}
+
+type sumResetter interface {
+	hash.Hash
+	SumReset([]byte) []byte
+}
+
+func TestSumReset(t *testing.T) {
+	for name, h := range map[string]hash.Hash{
+		"New":              New(),
+		"NewWithPrefix":    NewWithPrefix(nil),
+		"NewWithProgress":  NewWithProgress(func(uint64) {}),
+		"NewWithBlockHook": NewWithBlockHook(func(uint64) {}),
+	} {
+		sr := h.(sumResetter)
+		for _, msg := range []string{"first", strings.Repeat("second", 30), ""} {
+			sr.Write([]byte(msg))
+			want := Sum([]byte(msg))
+			if got := sr.SumReset(nil); !bytes.Equal(got, want[:]) {
+				t.Fatalf("%s: %q: got %x, want %x", name, msg, got, want)
+			}
+		}
+	}
+
+	p := NewWithPrefix([]byte("label")).(sumResetter)
+	p.Write([]byte("a"))
+	p.SumReset(nil)
+	p.Write([]byte("b"))
+	if got, want := p.Sum(nil), Sum([]byte("labelb")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("prefix after SumReset: got %x, want %x", got, want)
+	}
+}
+
+func TestWipe(t *testing.T) {
+	d := New().(*digest)
+	io.WriteString(d, strings.Repeat("secret", 20))
+	d.Wipe()
+	if *d != (digest{}) {
+		t.Fatalf("state after Wipe = %+v, want all zero", *d)
+	}
+
+	d.Reset()
+	io.WriteString(d, "abc")
+	if got, want := d.Sum(nil), Sum([]byte("abc")); !bytes.Equal(got, want[:]) {
+		t.Fatalf("after Wipe and Reset: got %x, want %x", got, want)
+	}
+}
+
+func TestSumDouble(t *testing.T) {
+	for _, g := range []struct {
+		in  string
+		out string
+	}{
+		{"", "8fbd432a738f0bcba72744b56cb733dc7f2aa6a0b8ab2c52c852967da1a555b1"},
+		{"abc", "bc123c90c9b8e9a44d2075e9c202c4638c63f8f6355c30c5365ff25d613f8adc"},
+	} {
+		sum := SumDouble([]byte(g.in))
+		if s := fmt.Sprintf("%x", sum); s != g.out {
+			t.Fatalf("SumDouble(%q) \ngot : %s \nwant: %s", g.in, s, g.out)
+		}
+		inner := Sum([]byte(g.in))
+		if want := Sum(inner[:]); sum != want {
+			t.Fatalf("SumDouble(%q) = %x, want %x", g.in, sum, want)
+		}
+	}
+}
+
+func TestSumAppend(t *testing.T) {
+	dst := []byte("prefix")
+	out := SumAppend(dst, []byte("abc"))
+	if len(out) != len(dst)+Size {
+		t.Fatalf("SumAppend appended %d bytes, want %d", len(out)-len(dst), Size)
+	}
+	want := Sum([]byte("abc"))
+	if string(out[:len(dst)]) != "prefix" || !bytes.Equal(out[len(dst):], want[:]) {
+		t.Fatalf("SumAppend = %x, want %x%x", out, dst, want)
+	}
+	if out := SumAppend(nil, nil); len(out) != Size {
+		t.Fatalf("SumAppend(nil, nil) has length %d", len(out))
+	}
+}
+
+func TestSumString(t *testing.T) {
+	for _, n := range []int{0, 3, 63, 64, 65, 1000} {
+		s := strings.Repeat("s", n)
+		if got, want := SumString(s), Sum([]byte(s)); got != want {
+			t.Fatalf("len %d: SumString = %x, want %x", n, got, want)
+		}
+	}
+	s := strings.Repeat("x", 1000)
+	if n := testing.AllocsPerRun(100, func() { SumString(s) }); n > 0 {
+		t.Fatalf("SumString allocates %v times, want 0", n)
+	}
+}
+
+func TestLargeInputAllocs(t *testing.T) {
+	large := make([]byte, 16<<20)
+	if n := testing.AllocsPerRun(2, func() { Sum(large) }); n > 0 {
+		t.Fatalf("Sum of %d bytes allocates %v times, want 0", len(large), n)
+	}
+	h := New()
+	out := make([]byte, 0, Size)
+	n := testing.AllocsPerRun(2, func() {
+		h.Reset()
+		h.Write(large[:3])
+		h.Write(large[3:])
+		h.Sum(out)
+	})
+	if n > 0 {
+		t.Fatalf("Write of %d bytes allocates %v times, want 0", len(large), n)
+	}
+}
+
+func TestSumSalted(t *testing.T) {
+	got := SumSalted([]byte("salt"), []byte("data"))
+	want := Sum([]byte("\x00\x00\x00\x00\x00\x00\x00\x04saltdata"))
+	if got != want {
+		t.Fatalf("SumSalted = %x, want %x", got, want)
+	}
+	if other := SumSalted([]byte("sal"), []byte("tdata")); other == got {
+		t.Fatal("moving the salt/data boundary did not change the digest")
+	}
+	if other := SumSalted(nil, []byte("saltdata")); other == got {
+		t.Fatal("empty salt collides with a salted input")
+	}
+}
+
+func TestSumWithBlocks(t *testing.T) {
+	for _, tt := range []struct{ n, blocks int }{
+		{0, 1}, {55, 1}, {56, 2}, {63, 2}, {64, 2}, {119, 2}, {120, 3}, {128, 3},
+	} {
+		data := testData(tt.n)
+		sum, blocks := SumWithBlocks(data)
+		if blocks != tt.blocks {
+			t.Fatalf("len %d: blocks = %d, want %d", tt.n, blocks, tt.blocks)
+		}
+		if want := Sum(data); sum != want {
+			t.Fatalf("len %d \ngot : %x \nwant: %x", tt.n, sum, want)
+		}
+		if padded := (tt.n + len(Padding(uint64(tt.n)))) / BlockSize; padded != blocks {
+			t.Fatalf("len %d: padded input is %d blocks, SumWithBlocks says %d", tt.n, padded, blocks)
+		}
+	}
+}
+
+func TestSumHalves(t *testing.T) {
+	for _, g := range golden[:3] {
+		hi, lo := SumHalves([]byte(g.in))
+		if s := fmt.Sprintf("%x%x", hi, lo); s != g.out {
+			t.Fatalf("SumHalves(%q) \ngot : %s \nwant: %s", g.in, s, g.out)
+		}
+	}
+}
+
+func TestSum64(t *testing.T) {
+	for _, g := range []struct {
+		in  string
+		out uint64
+	}{
+		{"", 0x1ab21d8355cfa17f},
+		{"abc", 0x66c7f0f462eeedd9},
+		{"user:42", 0x18e16169afdc2462},
+	} {
+		if got := Sum64([]byte(g.in)); got != g.out {
+			t.Fatalf("Sum64(%q) = %#016x, want %#016x", g.in, got, g.out)
+		}
+	}
+}
+
+func TestSumTruncated(t *testing.T) {
+	data := []byte("abc")
+	full := Sum(data)
+	for _, n := range []int{1, 16, Size} {
+		got, err := SumTruncated(data, n)
+		if err != nil {
+			t.Fatalf("SumTruncated(%d): %v", n, err)
+		}
+		if !bytes.Equal(got, full[:n]) {
+			t.Fatalf("SumTruncated(%d) = %x, want %x", n, got, full[:n])
+		}
+	}
+	for _, n := range []int{-1, 0, Size + 1} {
+		if _, err := SumTruncated(data, n); err == nil {
+			t.Fatalf("SumTruncated(%d) did not fail", n)
+		}
+	}
+}
+
+func TestSumPaddingBoundaries(t *testing.T) {
+	for _, n := range []int{0, 1, 55, 56, 57, 63, 64, 65, 119, 120, 121, 127, 128} {
+		data := testData(n)
+		want := SumReference(data)
+		if got := Sum(data); got != want {
+			t.Fatalf("len %d: Sum \ngot : %x \nwant: %x", n, got, want)
+		}
+		var into [Size]byte
+		SumInto(&into, data)
+		if into != want {
+			t.Fatalf("len %d: SumInto \ngot : %x \nwant: %x", n, into, want)
+		}
+		h := New()
+		h.Write(data)
+		if got := h.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("len %d: New().Sum \ngot : %x \nwant: %x", n, got, want)
+		}
+	}
+}
+
+func TestSumInto(t *testing.T) {
+	for _, n := range []int{0, 1, 55, 56, 64, 1000} {
+		data := bytes.Repeat([]byte{0x5a}, n)
+		var dst [Size]byte
+		SumInto(&dst, data)
+		if want := Sum(data); dst != want {
+			t.Fatalf("len %d: SumInto = %x, want %x", n, dst, want)
+		}
+	}
+}
+
+func TestNewWithState(t *testing.T) {
+	msg := []byte(strings.Repeat("0123456789", 20))
+	for _, split := range []int{0, 5, 64, 100, len(msg)} {
+		h := New()
+		h.Write(msg[:split])
+		state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+
+		// Pick the components out of the documented wire format.
+		var iv [8]uint32
+		b := state[len(magic):]
+		for i := range iv {
+			b, iv[i] = consumeUint32(b)
+		}
+		nx := int(state[marshaledSize-9])
+		_, total := consumeUint64(state[marshaledSize-8:])
+
+		h2, err := NewWithState(iv, b[:nx], total)
+		if err != nil {
+			t.Fatalf("split %d: NewWithState: %v", split, err)
+		}
+		h2.Write(msg[split:])
+		want := Sum(msg)
+		if got := h2.Sum(nil); !bytes.Equal(got, want[:]) {
+			t.Fatalf("split %d: got %x, want %x", split, got, want)
+		}
+	}
+}
+
+func TestNewWithStateInvalid(t *testing.T) {
+	if _, err := NewWithState(iv, make([]byte, BlockSize), BlockSize); err == nil {
+		t.Fatal("NewWithState accepted a full block of buffered input")
+	}
+	if _, err := NewWithState(iv, make([]byte, 3), 4); err == nil {
+		t.Fatal("NewWithState accepted inconsistent total")
+	}
+	if _, err := NewWithState(iv, make([]byte, 3), BlockSize+3); err != nil {
+		t.Fatalf("NewWithState rejected a valid state: %v", err)
+	}
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	d := New().(*digest)
+	d.Write([]byte(strings.Repeat("prefix", 20)))
+	s := d.Checkpoint()
+	d.Write([]byte("first continuation"))
+	d.Restore(s)
+	d.Write([]byte("second continuation"))
+
+	want := Sum([]byte(strings.Repeat("prefix", 20) + "second continuation"))
+	if got := d.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("got %x, want %x", got, want)
+	}
+
+	// A snapshot can be restored more than once.
+	d.Restore(s)
+	d.Write([]byte("second continuation"))
+	if got := d.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("second restore: got %x, want %x", got, want)
+	}
+}
+
 var bench = New()
 var buf = make([]byte, 8192)
 
@@ -97,3 +791,123 @@ func BenchmarkHash(b *testing.B) {
 		})
 	}
 }
+
+var benchString = strings.Repeat("a", 100)
+
+func BenchmarkWriteString(b *testing.B) {
+	sw := bench.(io.StringWriter)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchString)))
+	for i := 0; i < b.N; i++ {
+		bench.Reset()
+		sw.WriteString(benchString)
+	}
+}
+
+func BenchmarkWriteConvertedString(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchString)))
+	for i := 0; i < b.N; i++ {
+		bench.Reset()
+		bench.Write([]byte(benchString))
+	}
+}
+
+func BenchmarkSumDouble(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchString)))
+	for i := 0; i < b.N; i++ {
+		SumDouble([]byte(benchString))
+	}
+}
+
+func BenchmarkSumTwice(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchString)))
+	for i := 0; i < b.N; i++ {
+		sum := Sum([]byte(benchString))
+		Sum(sum[:])
+	}
+}
+
+func BenchmarkSumInto(b *testing.B) {
+	var dst [Size]byte
+	b.SetBytes(int64(len(benchString)))
+	for i := 0; i < b.N; i++ {
+		SumInto(&dst, []byte(benchString))
+	}
+}
+
+func BenchmarkSum(b *testing.B) {
+	var dst [Size]byte
+	b.SetBytes(int64(len(benchString)))
+	for i := 0; i < b.N; i++ {
+		dst = Sum([]byte(benchString))
+	}
+	_ = dst
+}
+
+func benchmarkSumSmall(b *testing.B, n int) {
+	data := buf[:n]
+	b.SetBytes(int64(n))
+	for i := 0; i < b.N; i++ {
+		Sum(data)
+	}
+}
+
+func BenchmarkSum8(b *testing.B)  { benchmarkSumSmall(b, 8) }
+func BenchmarkSum32(b *testing.B) { benchmarkSumSmall(b, 32) }
+func BenchmarkSum55(b *testing.B) { benchmarkSumSmall(b, 55) }
+func BenchmarkSum64(b *testing.B) { benchmarkSumSmall(b, 64) }
+
+// BenchmarkSumMethod measures the cost of finalizing a hash that is kept
+// and written to again, as in a rolling checksum.
+func BenchmarkSumMethod(b *testing.B) {
+	h := New()
+	var sum [Size]byte
+	b.SetBytes(8)
+	for i := 0; i < b.N; i++ {
+		h.Write(buf[:8])
+		h.Sum(sum[:0])
+	}
+}
+
+func BenchmarkSumString(b *testing.B) {
+	s := string(buf[:1024])
+	b.ReportAllocs()
+	b.SetBytes(int64(len(s)))
+	for i := 0; i < b.N; i++ {
+		SumString(s)
+	}
+}
+
+func BenchmarkSumAppend(b *testing.B) {
+	msgs := make([][]byte, 100)
+	for i := range msgs {
+		msgs[i] = buf[i : i+64]
+	}
+	out := make([]byte, 0, len(msgs)*Size)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(msgs) * 64))
+	for i := 0; i < b.N; i++ {
+		out = out[:0]
+		for _, m := range msgs {
+			out = SumAppend(out, m)
+		}
+	}
+}
+
+func BenchmarkWriteByte(b *testing.B) {
+	bw := bench.(io.ByteWriter)
+	b.SetBytes(1)
+	for i := 0; i < b.N; i++ {
+		bw.WriteByte(byte(i))
+	}
+}
+
+func BenchmarkWriteOneByteSlice(b *testing.B) {
+	b.SetBytes(1)
+	for i := 0; i < b.N; i++ {
+		bench.Write(buf[:1])
+	}
+}
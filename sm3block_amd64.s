@@ -0,0 +1,278 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 && !purego && !sm3small
+// +build amd64,!purego,!sm3small
+
+#include "textflag.h"
+
+// SM3 block routine for amd64. See sm3block.go for the reference
+// implementation and the draft for the definitions.
+//
+// The message is expanded into W[0..67] on the stack first, then the state
+// a..h is kept in R8-R15 across the 64 rounds. Rather than shuffling
+// registers at the end of every round, the macros are invoked with the
+// register roles rotated, so that after 4 rounds the mapping is back where
+// it started.
+
+// Wt = Mt; for 0 <= t <= 15
+#define MSGSCHEDULE0(index) \
+	MOVL	(index*4)(SI), AX; \
+	BSWAPL	AX; \
+	MOVL	AX, (index*4)(SP)
+
+// Wt = P1(Wt-16 ^ Wt-9 ^ (Wt-3 <<< 15)) ^ (Wt-13 <<< 7) ^ Wt-6; for 16 <= t <= 67
+// P1(x) = x ^ (x <<< 15) ^ (x <<< 23)
+#define MSGSCHEDULE1(index) \
+	MOVL	((index-3)*4)(SP), AX; \
+	ROLL	$15, AX; \
+	XORL	((index-16)*4)(SP), AX; \
+	XORL	((index-9)*4)(SP), AX; \
+	MOVL	AX, BX; \
+	ROLL	$15, BX; \
+	MOVL	AX, CX; \
+	ROLL	$23, CX; \
+	XORL	BX, AX; \
+	XORL	CX, AX; \
+	MOVL	((index-13)*4)(SP), BX; \
+	ROLL	$7, BX; \
+	XORL	BX, AX; \
+	XORL	((index-6)*4)(SP), AX; \
+	MOVL	AX, (index*4)(SP)
+
+// SS1 = ((a <<< 12) + e + (Tj <<< j)) <<< 7
+// SS2 = SS1 ^ (a <<< 12)
+// d = FF(a, b, c) + d + SS2 + (Wj ^ Wj+4)   (TT1)
+// h = GG(e, f, g) + h + SS1 + Wj            (TT2)
+// b = b <<< 9, f = f <<< 19, h = P0(h)
+//
+// FF0 and GG0 leave their result in CX and may only clobber CX and DX.
+#define SM3ROUND(index, t, a, b, c, d, e, f, g, h, FF, GG) \
+	MOVL	a, AX; \
+	ROLL	$12, AX; \
+	MOVL	AX, BX; \
+	ADDL	e, BX; \
+	ADDL	$t, BX; \
+	ROLL	$7, BX; \
+	XORL	BX, AX; \
+	ADDL	(index*4)(SP), BX; \
+	MOVL	(index*4)(SP), CX; \
+	XORL	((index+4)*4)(SP), CX; \
+	ADDL	CX, AX; \
+	ADDL	AX, d; \
+	FF(a, b, c); \
+	ADDL	CX, d; \
+	ADDL	BX, h; \
+	GG(e, f, g); \
+	ADDL	CX, h; \
+	MOVL	h, CX; \
+	MOVL	h, DX; \
+	ROLL	$9, CX; \
+	ROLL	$17, DX; \
+	XORL	CX, h; \
+	XORL	DX, h; \
+	ROLL	$9, b; \
+	ROLL	$19, f
+
+// FF0(x, y, z) = GG0(x, y, z) = x ^ y ^ z
+#define XOR3(x, y, z) \
+	MOVL	x, CX; \
+	XORL	y, CX; \
+	XORL	z, CX
+
+// FF1(x, y, z) = (x & y) | (x & z) | (y & z) = (x & y) | ((x | y) & z)
+#define FF1(x, y, z) \
+	MOVL	x, CX; \
+	MOVL	x, DX; \
+	ANDL	y, CX; \
+	ORL	y, DX; \
+	ANDL	z, DX; \
+	ORL	DX, CX
+
+// GG1(x, y, z) = (x & y) | (^x & z) = ((y ^ z) & x) ^ z
+#define GG1(x, y, z) \
+	MOVL	y, CX; \
+	XORL	z, CX; \
+	ANDL	x, CX; \
+	XORL	z, CX
+
+// func block(dig *digest, p []byte)
+TEXT ·block(SB), 0, $280-32
+	MOVQ	p_base+8(FP), SI
+	MOVQ	p_len+16(FP), DX
+	SHRQ	$6, DX
+	SHLQ	$6, DX
+	LEAQ	(SI)(DX*1), DI
+	MOVQ	DI, 272(SP)
+	CMPQ	SI, DI
+	JEQ	end
+
+	MOVQ	dig+0(FP), DI
+	MOVL	(0*4)(DI), R8
+	MOVL	(1*4)(DI), R9
+	MOVL	(2*4)(DI), R10
+	MOVL	(3*4)(DI), R11
+	MOVL	(4*4)(DI), R12
+	MOVL	(5*4)(DI), R13
+	MOVL	(6*4)(DI), R14
+	MOVL	(7*4)(DI), R15
+
+loop:
+	MSGSCHEDULE0(0)
+	MSGSCHEDULE0(1)
+	MSGSCHEDULE0(2)
+	MSGSCHEDULE0(3)
+	MSGSCHEDULE0(4)
+	MSGSCHEDULE0(5)
+	MSGSCHEDULE0(6)
+	MSGSCHEDULE0(7)
+	MSGSCHEDULE0(8)
+	MSGSCHEDULE0(9)
+	MSGSCHEDULE0(10)
+	MSGSCHEDULE0(11)
+	MSGSCHEDULE0(12)
+	MSGSCHEDULE0(13)
+	MSGSCHEDULE0(14)
+	MSGSCHEDULE0(15)
+	MSGSCHEDULE1(16)
+	MSGSCHEDULE1(17)
+	MSGSCHEDULE1(18)
+	MSGSCHEDULE1(19)
+	MSGSCHEDULE1(20)
+	MSGSCHEDULE1(21)
+	MSGSCHEDULE1(22)
+	MSGSCHEDULE1(23)
+	MSGSCHEDULE1(24)
+	MSGSCHEDULE1(25)
+	MSGSCHEDULE1(26)
+	MSGSCHEDULE1(27)
+	MSGSCHEDULE1(28)
+	MSGSCHEDULE1(29)
+	MSGSCHEDULE1(30)
+	MSGSCHEDULE1(31)
+	MSGSCHEDULE1(32)
+	MSGSCHEDULE1(33)
+	MSGSCHEDULE1(34)
+	MSGSCHEDULE1(35)
+	MSGSCHEDULE1(36)
+	MSGSCHEDULE1(37)
+	MSGSCHEDULE1(38)
+	MSGSCHEDULE1(39)
+	MSGSCHEDULE1(40)
+	MSGSCHEDULE1(41)
+	MSGSCHEDULE1(42)
+	MSGSCHEDULE1(43)
+	MSGSCHEDULE1(44)
+	MSGSCHEDULE1(45)
+	MSGSCHEDULE1(46)
+	MSGSCHEDULE1(47)
+	MSGSCHEDULE1(48)
+	MSGSCHEDULE1(49)
+	MSGSCHEDULE1(50)
+	MSGSCHEDULE1(51)
+	MSGSCHEDULE1(52)
+	MSGSCHEDULE1(53)
+	MSGSCHEDULE1(54)
+	MSGSCHEDULE1(55)
+	MSGSCHEDULE1(56)
+	MSGSCHEDULE1(57)
+	MSGSCHEDULE1(58)
+	MSGSCHEDULE1(59)
+	MSGSCHEDULE1(60)
+	MSGSCHEDULE1(61)
+	MSGSCHEDULE1(62)
+	MSGSCHEDULE1(63)
+	MSGSCHEDULE1(64)
+	MSGSCHEDULE1(65)
+	MSGSCHEDULE1(66)
+	MSGSCHEDULE1(67)
+
+	SM3ROUND(0, 0x79cc4519, R8, R9, R10, R11, R12, R13, R14, R15, XOR3, XOR3)
+	SM3ROUND(1, 0xf3988a32, R11, R8, R9, R10, R15, R12, R13, R14, XOR3, XOR3)
+	SM3ROUND(2, 0xe7311465, R10, R11, R8, R9, R14, R15, R12, R13, XOR3, XOR3)
+	SM3ROUND(3, 0xce6228cb, R9, R10, R11, R8, R13, R14, R15, R12, XOR3, XOR3)
+	SM3ROUND(4, 0x9cc45197, R8, R9, R10, R11, R12, R13, R14, R15, XOR3, XOR3)
+	SM3ROUND(5, 0x3988a32f, R11, R8, R9, R10, R15, R12, R13, R14, XOR3, XOR3)
+	SM3ROUND(6, 0x7311465e, R10, R11, R8, R9, R14, R15, R12, R13, XOR3, XOR3)
+	SM3ROUND(7, 0xe6228cbc, R9, R10, R11, R8, R13, R14, R15, R12, XOR3, XOR3)
+	SM3ROUND(8, 0xcc451979, R8, R9, R10, R11, R12, R13, R14, R15, XOR3, XOR3)
+	SM3ROUND(9, 0x988a32f3, R11, R8, R9, R10, R15, R12, R13, R14, XOR3, XOR3)
+	SM3ROUND(10, 0x311465e7, R10, R11, R8, R9, R14, R15, R12, R13, XOR3, XOR3)
+	SM3ROUND(11, 0x6228cbce, R9, R10, R11, R8, R13, R14, R15, R12, XOR3, XOR3)
+	SM3ROUND(12, 0xc451979c, R8, R9, R10, R11, R12, R13, R14, R15, XOR3, XOR3)
+	SM3ROUND(13, 0x88a32f39, R11, R8, R9, R10, R15, R12, R13, R14, XOR3, XOR3)
+	SM3ROUND(14, 0x11465e73, R10, R11, R8, R9, R14, R15, R12, R13, XOR3, XOR3)
+	SM3ROUND(15, 0x228cbce6, R9, R10, R11, R8, R13, R14, R15, R12, XOR3, XOR3)
+	SM3ROUND(16, 0x9d8a7a87, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(17, 0x3b14f50f, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(18, 0x7629ea1e, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(19, 0xec53d43c, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(20, 0xd8a7a879, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(21, 0xb14f50f3, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(22, 0x629ea1e7, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(23, 0xc53d43ce, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(24, 0x8a7a879d, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(25, 0x14f50f3b, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(26, 0x29ea1e76, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(27, 0x53d43cec, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(28, 0xa7a879d8, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(29, 0x4f50f3b1, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(30, 0x9ea1e762, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(31, 0x3d43cec5, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(32, 0x7a879d8a, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(33, 0xf50f3b14, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(34, 0xea1e7629, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(35, 0xd43cec53, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(36, 0xa879d8a7, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(37, 0x50f3b14f, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(38, 0xa1e7629e, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(39, 0x43cec53d, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(40, 0x879d8a7a, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(41, 0x0f3b14f5, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(42, 0x1e7629ea, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(43, 0x3cec53d4, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(44, 0x79d8a7a8, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(45, 0xf3b14f50, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(46, 0xe7629ea1, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(47, 0xcec53d43, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(48, 0x9d8a7a87, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(49, 0x3b14f50f, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(50, 0x7629ea1e, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(51, 0xec53d43c, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(52, 0xd8a7a879, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(53, 0xb14f50f3, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(54, 0x629ea1e7, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(55, 0xc53d43ce, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(56, 0x8a7a879d, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(57, 0x14f50f3b, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(58, 0x29ea1e76, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(59, 0x53d43cec, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+	SM3ROUND(60, 0xa7a879d8, R8, R9, R10, R11, R12, R13, R14, R15, FF1, GG1)
+	SM3ROUND(61, 0x4f50f3b1, R11, R8, R9, R10, R15, R12, R13, R14, FF1, GG1)
+	SM3ROUND(62, 0x9ea1e762, R10, R11, R8, R9, R14, R15, R12, R13, FF1, GG1)
+	SM3ROUND(63, 0x3d43cec5, R9, R10, R11, R8, R13, R14, R15, R12, FF1, GG1)
+
+	XORL	(0*4)(DI), R8
+	MOVL	R8, (0*4)(DI)
+	XORL	(1*4)(DI), R9
+	MOVL	R9, (1*4)(DI)
+	XORL	(2*4)(DI), R10
+	MOVL	R10, (2*4)(DI)
+	XORL	(3*4)(DI), R11
+	MOVL	R11, (3*4)(DI)
+	XORL	(4*4)(DI), R12
+	MOVL	R12, (4*4)(DI)
+	XORL	(5*4)(DI), R13
+	MOVL	R13, (5*4)(DI)
+	XORL	(6*4)(DI), R14
+	MOVL	R14, (6*4)(DI)
+	XORL	(7*4)(DI), R15
+	MOVL	R15, (7*4)(DI)
+
+	ADDQ	$64, SI
+	CMPQ	SI, 272(SP)
+	JB	loop
+
+end:
+	RET
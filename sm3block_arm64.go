@@ -0,0 +1,30 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build arm64 && !purego && !sm3small
+// +build arm64,!purego,!sm3small
+
+package sm3
+
+import "golang.org/x/sys/cpu"
+
+var useSM3 = cpu.ARM64.HasSM3
+
+var implementation = func() string {
+	if useSM3 {
+		return "arm64-sm3"
+	}
+	return "generic"
+}()
+
+//go:noescape
+func blockSM3(dig *digest, p []byte)
+
+func block(dig *digest, p []byte) {
+	if useSM3 {
+		blockSM3(dig, p)
+		return
+	}
+	blockGeneric(dig, p)
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build arm64 && !purego && !sm3small
+// +build arm64,!purego,!sm3small
+
+#include "textflag.h"
+
+// SM3 block routine for arm64 using the ARMv8.2 SM3 instructions.
+//
+// The state is kept as V8 = [a, b, c, d] and V9 = [e, f, g, h], with a and
+// e in the most significant lane, which is the layout SM3TT1x and SM3TT2x
+// operate on. The message words live in V0-V4, four per register, and are
+// expanded four at a time with SM3PARTW1/SM3PARTW2 while the previous
+// group is consumed. V11/V12 alternate holding Tj <<< j in the top lane.
+//
+// The assembler does not know the SM3 instructions, so they are emitted as
+// WORDs. Register arguments are plain vector register numbers.
+
+#define SM3SS1(d, n, m, a) WORD $(0xce400000 | (m<<16) | (a<<10) | (n<<5) | d)
+#define SM3TT1A(d, n, m, i) WORD $(0xce408000 | (m<<16) | (i<<12) | (n<<5) | d)
+#define SM3TT1B(d, n, m, i) WORD $(0xce408400 | (m<<16) | (i<<12) | (n<<5) | d)
+#define SM3TT2A(d, n, m, i) WORD $(0xce408800 | (m<<16) | (i<<12) | (n<<5) | d)
+#define SM3TT2B(d, n, m, i) WORD $(0xce408c00 | (m<<16) | (i<<12) | (n<<5) | d)
+#define SM3PARTW1(d, n, m) WORD $(0xce60c000 | (m<<16) | (n<<5) | d)
+#define SM3PARTW2(d, n, m) WORD $(0xce60c400 | (m<<16) | (n<<5) | d)
+
+// One round j. V5 = SS1, V10 holds W'j..W'j+3, s0 holds Wj..Wj+3 and
+// T1 = T0 <<< 1 for the next round.
+#define ROUND(tt1, tt2, s0, i, t0, T0, T1) \
+	SM3SS1(5, 8, t0, 9); \
+	VSHL	$1, T0.S4, T1.S4; \
+	VSRI	$31, T0.S4, T1.S4; \
+	tt1(8, 5, 10, i); \
+	tt2(9, 5, s0, i)
+
+// Four rounds over the message words in S0, with S1 holding the next four.
+#define QROUND(tt1, tt2, s0, S0, S1) \
+	VEOR	S1.B16, S0.B16, V10.B16; \
+	ROUND(tt1, tt2, s0, 0, 11, V11, V12); \
+	ROUND(tt1, tt2, s0, 1, 12, V12, V11); \
+	ROUND(tt1, tt2, s0, 2, 11, V11, V12); \
+	ROUND(tt1, tt2, s0, 3, 12, V12, V11)
+
+// First half of expanding S4 = W[j+16..j+19] from S0-S3 = W[j..j+15].
+// It is finished by SM3PARTW2(s4, 7, 6) once the rounds over S0 are done.
+#define SCHEDULE(s0, s3, s4, S0, S1, S2, S3, S4) \
+	VEXT	$12, S2.B16, S1.B16, S4.B16; \
+	VEXT	$12, S1.B16, S0.B16, V6.B16; \
+	VEXT	$8, S3.B16, S2.B16, V7.B16; \
+	SM3PARTW1(s4, s0, s3)
+
+// Four rounds that also expand the next group of message words.
+#define QROUNDW(tt1, tt2, s0, s3, s4, S0, S1, S2, S3, S4) \
+	SCHEDULE(s0, s3, s4, S0, S1, S2, S3, S4); \
+	QROUND(tt1, tt2, s0, S0, S1); \
+	SM3PARTW2(s4, 7, 6)
+
+// func blockSM3(dig *digest, p []byte)
+TEXT ·blockSM3(SB), NOSPLIT, $0-32
+	MOVD	dig+0(FP), R0
+	MOVD	p_base+8(FP), R1
+	MOVD	p_len+16(FP), R2
+	LSR	$6, R2
+	CBZ	R2, end
+
+	VLD1	(R0), [V8.S4, V9.S4]
+	VREV64	V8.S4, V8.S4
+	VREV64	V9.S4, V9.S4
+	VEXT	$8, V8.B16, V8.B16, V8.B16
+	VEXT	$8, V9.B16, V9.B16, V9.B16
+
+	MOVW	$0x79cc4519, R3 // T0
+	MOVW	$0x9d8a7a87, R4 // T16 <<< 16
+
+loop:
+	VLD1.P	64(R1), [V0.B16, V1.B16, V2.B16, V3.B16]
+	SUB	$1, R2
+
+	VMOV	V8.B16, V15.B16
+	VMOV	V9.B16, V16.B16
+
+	VREV32	V0.B16, V0.B16
+	VREV32	V1.B16, V1.B16
+	VREV32	V2.B16, V2.B16
+	VREV32	V3.B16, V3.B16
+
+	VMOV	R3, V11.S[3]
+
+	QROUNDW(SM3TT1A, SM3TT2A, 0, 3, 4, V0, V1, V2, V3, V4)
+	QROUNDW(SM3TT1A, SM3TT2A, 1, 4, 0, V1, V2, V3, V4, V0)
+	QROUNDW(SM3TT1A, SM3TT2A, 2, 0, 1, V2, V3, V4, V0, V1)
+	QROUNDW(SM3TT1A, SM3TT2A, 3, 1, 2, V3, V4, V0, V1, V2)
+
+	VMOV	R4, V11.S[3]
+
+	QROUNDW(SM3TT1B, SM3TT2B, 4, 2, 3, V4, V0, V1, V2, V3)
+	QROUNDW(SM3TT1B, SM3TT2B, 0, 3, 4, V0, V1, V2, V3, V4)
+	QROUNDW(SM3TT1B, SM3TT2B, 1, 4, 0, V1, V2, V3, V4, V0)
+	QROUNDW(SM3TT1B, SM3TT2B, 2, 0, 1, V2, V3, V4, V0, V1)
+	QROUNDW(SM3TT1B, SM3TT2B, 3, 1, 2, V3, V4, V0, V1, V2)
+	QROUNDW(SM3TT1B, SM3TT2B, 4, 2, 3, V4, V0, V1, V2, V3)
+	QROUNDW(SM3TT1B, SM3TT2B, 0, 3, 4, V0, V1, V2, V3, V4)
+	QROUNDW(SM3TT1B, SM3TT2B, 1, 4, 0, V1, V2, V3, V4, V0)
+	QROUNDW(SM3TT1B, SM3TT2B, 2, 0, 1, V2, V3, V4, V0, V1)
+	QROUND(SM3TT1B, SM3TT2B, 3, V3, V4)
+	QROUND(SM3TT1B, SM3TT2B, 4, V4, V0)
+	QROUND(SM3TT1B, SM3TT2B, 0, V0, V1)
+
+	VEOR	V15.B16, V8.B16, V8.B16
+	VEOR	V16.B16, V9.B16, V9.B16
+
+	CBNZ	R2, loop
+
+	VREV64	V8.S4, V8.S4
+	VREV64	V9.S4, V9.S4
+	VEXT	$8, V8.B16, V8.B16, V8.B16
+	VEXT	$8, V9.B16, V9.B16, V9.B16
+	VST1	[V8.S4, V9.S4], (R0)
+
+end:
+	RET
@@ -0,0 +1,24 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build arm64 && !purego && !sm3small
+// +build arm64,!purego,!sm3small
+
+package sm3
+
+import "testing"
+
+func TestBlockSM3(t *testing.T) {
+	if !useSM3 {
+		t.Skip("SM3 instructions not supported")
+	}
+	testBlock(t, blockSM3)
+}
+
+func BenchmarkBlockSM3(b *testing.B) {
+	if !useSM3 {
+		b.Skip("SM3 instructions not supported")
+	}
+	benchmarkBlock(b, blockSM3)
+}
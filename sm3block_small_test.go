@@ -0,0 +1,36 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build sm3small
+// +build sm3small
+
+package sm3
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBlockSmall checks the compact block function against the standard
+// vectors, against SumReference, which shares no code with it, and against
+// the unrolled blockGeneric on random multi-block inputs.
+func TestBlockSmall(t *testing.T) {
+	if impl := Implementation(); impl != "small" {
+		t.Fatalf("Implementation = %q, want small", impl)
+	}
+	for _, g := range golden {
+		if s := fmt.Sprintf("%x", Sum(goldenInput(g))); s != g.out {
+			t.Fatalf("Sum(%q) \ngot : %s \nwant: %s", g.in, s, g.out)
+		}
+	}
+	for n := 0; n <= 4*BlockSize; n++ {
+		data := testData(n)
+		if got, want := Sum(data), SumReference(data); got != want {
+			t.Fatalf("len %d: Sum = %x, SumReference = %x", n, got, want)
+		}
+	}
+	testBlock(t, blockSmall)
+}
+
+func BenchmarkBlockSmall(b *testing.B) { benchmarkBlock(b, blockSmall) }
@@ -0,0 +1,147 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"math/bits"
+	"math/rand"
+	"testing"
+	"unsafe"
+)
+
+// TestBlockGeneric checks that the block function in use agrees with the
+// pure Go implementation.
+func TestBlockGeneric(t *testing.T) { testBlock(t, block) }
+
+// TestBlockKnownAnswer feeds the padded message "abc" from example 1 of
+// GB/T 32905-2016 straight to the block functions, so the big-endian word
+// loads and stores are checked against a fixed digest on every platform.
+func TestBlockKnownAnswer(t *testing.T) {
+	var p [BlockSize]byte
+	copy(p[:], "abc\x80")
+	p[BlockSize-1] = 3 * 8
+	want := [8]uint32{
+		0x66c7f0f4, 0x62eeedd9, 0xd1f2d46b, 0xdc10e4e2,
+		0x4167c487, 0x5cf2f7a2, 0x297da02b, 0x8f4ba8e0,
+	}
+	for _, f := range []struct {
+		name  string
+		block func(*digest, []byte)
+	}{
+		{"block", block},
+		{"blockGeneric", blockGeneric},
+	} {
+		var d digest
+		d.Reset()
+		f.block(&d, p[:])
+		if d.h != want {
+			t.Fatalf("%s \ngot : %08x \nwant: %08x", f.name, d.h, want)
+		}
+	}
+}
+
+func TestRoundConstants(t *testing.T) {
+	for j, got := range tj {
+		c := uint32(t0)
+		if j >= 16 {
+			c = t16
+		}
+		if want := bits.RotateLeft32(c, j%32); got != want {
+			t.Fatalf("tj[%d] \ngot : %08x \nwant: %08x", j, got, want)
+		}
+	}
+}
+
+func TestImplementation(t *testing.T) {
+	switch impl := Implementation(); impl {
+	case "amd64", "arm64-sm3", "generic", "small":
+	default:
+		t.Fatalf("unknown implementation %q", impl)
+	}
+}
+
+func testBlock(t *testing.T, block func(*digest, []byte)) {
+	rng := rand.New(rand.NewSource(1))
+	p := make([]byte, 16*BlockSize)
+	for i := 0; i < 100; i++ {
+		rng.Read(p)
+		n := rng.Intn(len(p)/BlockSize+1) * BlockSize
+
+		var d1, d2 digest
+		d1.Reset()
+		for j := range d1.h {
+			d1.h[j] = rng.Uint32()
+		}
+		d2 = d1
+
+		block(&d1, p[:n])
+		blockGeneric(&d2, p[:n])
+		if d1.h != d2.h {
+			t.Fatalf("%d blocks: block = %08x, blockGeneric = %08x", n/BlockSize, d1.h, d2.h)
+		}
+	}
+}
+
+func benchmarkBlock(b *testing.B, f func(*digest, []byte)) {
+	benchmarkBlocks(b, f, 16)
+}
+
+func benchmarkBlocks(b *testing.B, f func(*digest, []byte), n int) {
+	var d digest
+	d.Reset()
+	p := make([]byte, n*BlockSize)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		f(&d, p)
+	}
+}
+
+func BenchmarkBlock(b *testing.B)         { benchmarkBlock(b, block) }
+func BenchmarkBlockGeneric(b *testing.B)  { benchmarkBlock(b, blockGeneric) }
+func BenchmarkBlockGeneric1(b *testing.B) { benchmarkBlocks(b, blockGeneric, 1) }
+
+// alignedBlocks returns n blocks of input starting off bytes past a 64 byte
+// boundary.
+func alignedBlocks(n, off int) []byte {
+	p := make([]byte, n*BlockSize+2*BlockSize)
+	skip := (BlockSize - int(uintptr(unsafe.Pointer(&p[0]))%BlockSize)) % BlockSize
+	return p[skip+off : skip+off+n*BlockSize]
+}
+
+// TestBlockAlignment checks that block gives the same result wherever its
+// input starts.
+func TestBlockAlignment(t *testing.T) {
+	var want [8]uint32
+	for off := 0; off < 16; off++ {
+		p := alignedBlocks(4, off)
+		for i := range p {
+			p[i] = byte(i)
+		}
+		var d digest
+		d.Reset()
+		block(&d, p)
+		if off == 0 {
+			want = d.h
+		} else if d.h != want {
+			t.Fatalf("offset %d: got %08x, want %08x", off, d.h, want)
+		}
+	}
+}
+
+// The block functions load words with explicit byte swaps, so unaligned
+// input costs no more than aligned input; these benchmarks document that
+// there is nothing to gain from aligning the block buffer.
+func benchmarkBlockOffset(b *testing.B, off int) {
+	var d digest
+	d.Reset()
+	p := alignedBlocks(8192/BlockSize, off)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		block(&d, p)
+	}
+}
+
+func BenchmarkBlockAligned(b *testing.B)   { benchmarkBlockOffset(b, 0) }
+func BenchmarkBlockUnaligned(b *testing.B) { benchmarkBlockOffset(b, 1) }
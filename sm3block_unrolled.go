@@ -0,0 +1,153 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// tj holds the round constants T_j already rotated left by j mod 32. It is
+// a literal rather than computed at init so that the linker can drop it,
+// with blockGeneric, from sm3small binaries.
+var tj = [64]uint32{
+	0x79cc4519, 0xf3988a32, 0xe7311465, 0xce6228cb,
+	0x9cc45197, 0x3988a32f, 0x7311465e, 0xe6228cbc,
+	0xcc451979, 0x988a32f3, 0x311465e7, 0x6228cbce,
+	0xc451979c, 0x88a32f39, 0x11465e73, 0x228cbce6,
+	0x9d8a7a87, 0x3b14f50f, 0x7629ea1e, 0xec53d43c,
+	0xd8a7a879, 0xb14f50f3, 0x629ea1e7, 0xc53d43ce,
+	0x8a7a879d, 0x14f50f3b, 0x29ea1e76, 0x53d43cec,
+	0xa7a879d8, 0x4f50f3b1, 0x9ea1e762, 0x3d43cec5,
+	0x7a879d8a, 0xf50f3b14, 0xea1e7629, 0xd43cec53,
+	0xa879d8a7, 0x50f3b14f, 0xa1e7629e, 0x43cec53d,
+	0x879d8a7a, 0x0f3b14f5, 0x1e7629ea, 0x3cec53d4,
+	0x79d8a7a8, 0xf3b14f50, 0xe7629ea1, 0xcec53d43,
+	0x9d8a7a87, 0x3b14f50f, 0x7629ea1e, 0xec53d43c,
+	0xd8a7a879, 0xb14f50f3, 0x629ea1e7, 0xc53d43ce,
+	0x8a7a879d, 0x14f50f3b, 0x29ea1e76, 0x53d43cec,
+	0xa7a879d8, 0x4f50f3b1, 0x9ea1e762, 0x3d43cec5,
+}
+
+// blockGeneric is the portable compression function. All of its arithmetic
+// is on 32-bit words, so it needs no separate variant for 32-bit targets
+// such as 386, arm and mipsle, where it is already the block in use.
+func blockGeneric(dig *digest, p []byte) {
+	var (
+		w [68]uint32
+
+		a12, ss1, tt1, tt2 uint32
+	)
+
+	h0, h1, h2, h3 := dig.h[0], dig.h[1], dig.h[2], dig.h[3]
+	h4, h5, h6, h7 := dig.h[4], dig.h[5], dig.h[6], dig.h[7]
+
+	for len(p) >= chunk {
+		q := p[:chunk]
+
+		// expand data
+		for j := 0; j < 16; j++ {
+			w[j] = binary.BigEndian.Uint32(q[j*4:])
+		}
+		for j := 16; j < 68; j++ {
+			w[j] = p1(w[j-16]^w[j-9]^bits.RotateLeft32(w[j-3], 15)) ^
+				bits.RotateLeft32(w[j-13], 7) ^ w[j-6]
+		}
+
+		a, b, c, d, e, f, g, h := h0, h1, h2, h3, h4, h5, h6, h7
+
+		// compress function, four rounds at a time; instead of shifting
+		// the working registers every round their roles are rotated, so
+		// each round only overwrites the register that would fall off.
+		for j := 0; j < 16; j += 4 {
+			a12 = bits.RotateLeft32(a, 12)
+			ss1 = bits.RotateLeft32(a12+e+tj[j], 7)
+			tt1 = ff0(a, b, c) + d + (ss1 ^ a12) + (w[j] ^ w[j+4])
+			tt2 = gg0(e, f, g) + h + ss1 + w[j]
+			b = bits.RotateLeft32(b, 9)
+			d = tt1
+			f = bits.RotateLeft32(f, 19)
+			h = p0(tt2)
+
+			a12 = bits.RotateLeft32(d, 12)
+			ss1 = bits.RotateLeft32(a12+h+tj[j+1], 7)
+			tt1 = ff0(d, a, b) + c + (ss1 ^ a12) + (w[j+1] ^ w[j+5])
+			tt2 = gg0(h, e, f) + g + ss1 + w[j+1]
+			a = bits.RotateLeft32(a, 9)
+			c = tt1
+			e = bits.RotateLeft32(e, 19)
+			g = p0(tt2)
+
+			a12 = bits.RotateLeft32(c, 12)
+			ss1 = bits.RotateLeft32(a12+g+tj[j+2], 7)
+			tt1 = ff0(c, d, a) + b + (ss1 ^ a12) + (w[j+2] ^ w[j+6])
+			tt2 = gg0(g, h, e) + f + ss1 + w[j+2]
+			d = bits.RotateLeft32(d, 9)
+			b = tt1
+			h = bits.RotateLeft32(h, 19)
+			f = p0(tt2)
+
+			a12 = bits.RotateLeft32(b, 12)
+			ss1 = bits.RotateLeft32(a12+f+tj[j+3], 7)
+			tt1 = ff0(b, c, d) + a + (ss1 ^ a12) + (w[j+3] ^ w[j+7])
+			tt2 = gg0(f, g, h) + e + ss1 + w[j+3]
+			c = bits.RotateLeft32(c, 9)
+			a = tt1
+			g = bits.RotateLeft32(g, 19)
+			e = p0(tt2)
+		}
+		for j := 16; j < 64; j += 4 {
+			a12 = bits.RotateLeft32(a, 12)
+			ss1 = bits.RotateLeft32(a12+e+tj[j], 7)
+			tt1 = ff16(a, b, c) + d + (ss1 ^ a12) + (w[j] ^ w[j+4])
+			tt2 = gg16(e, f, g) + h + ss1 + w[j]
+			b = bits.RotateLeft32(b, 9)
+			d = tt1
+			f = bits.RotateLeft32(f, 19)
+			h = p0(tt2)
+
+			a12 = bits.RotateLeft32(d, 12)
+			ss1 = bits.RotateLeft32(a12+h+tj[j+1], 7)
+			tt1 = ff16(d, a, b) + c + (ss1 ^ a12) + (w[j+1] ^ w[j+5])
+			tt2 = gg16(h, e, f) + g + ss1 + w[j+1]
+			a = bits.RotateLeft32(a, 9)
+			c = tt1
+			e = bits.RotateLeft32(e, 19)
+			g = p0(tt2)
+
+			a12 = bits.RotateLeft32(c, 12)
+			ss1 = bits.RotateLeft32(a12+g+tj[j+2], 7)
+			tt1 = ff16(c, d, a) + b + (ss1 ^ a12) + (w[j+2] ^ w[j+6])
+			tt2 = gg16(g, h, e) + f + ss1 + w[j+2]
+			d = bits.RotateLeft32(d, 9)
+			b = tt1
+			h = bits.RotateLeft32(h, 19)
+			f = p0(tt2)
+
+			a12 = bits.RotateLeft32(b, 12)
+			ss1 = bits.RotateLeft32(a12+f+tj[j+3], 7)
+			tt1 = ff16(b, c, d) + a + (ss1 ^ a12) + (w[j+3] ^ w[j+7])
+			tt2 = gg16(f, g, h) + e + ss1 + w[j+3]
+			c = bits.RotateLeft32(c, 9)
+			a = tt1
+			g = bits.RotateLeft32(g, 19)
+			e = p0(tt2)
+		}
+
+		h0 ^= a
+		h1 ^= b
+		h2 ^= c
+		h3 ^= d
+		h4 ^= e
+		h5 ^= f
+		h6 ^= g
+		h7 ^= h
+
+		p = p[chunk:]
+	}
+
+	dig.h[0], dig.h[1], dig.h[2], dig.h[3] = h0, h1, h2, h3
+	dig.h[4], dig.h[5], dig.h[6], dig.h[7] = h4, h5, h6, h7
+}
@@ -0,0 +1,19 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+// Sum4 returns the SM3 checksums of four independent messages, which may
+// differ in length. On amd64 with AVX2 the messages are hashed in parallel,
+// one per vector lane; elsewhere Sum4 is equivalent to four calls to Sum.
+func Sum4(msgs [4][]byte) [4][Size]byte {
+	return sum4(msgs)
+}
+
+func sum4Generic(msgs [4][]byte) (sums [4][Size]byte) {
+	for i, m := range msgs {
+		sums[i] = Sum(m)
+	}
+	return
+}
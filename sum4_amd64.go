@@ -0,0 +1,85 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 && !purego && !sm3small
+// +build amd64,!purego,!sm3small
+
+package sm3
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/sys/cpu"
+)
+
+var useAVX2 = cpu.X86.HasAVX2
+
+// block4 compresses one block of each of four messages. h holds the four
+// chaining values transposed, h[i][lane] being word i of lane.
+//
+//go:noescape
+func block4(h *[8][4]uint32, p *[4]*byte)
+
+func sum4(msgs [4][]byte) (sums [4][Size]byte) {
+	if !useAVX2 {
+		return sum4Generic(msgs)
+	}
+
+	var (
+		h     [8][4]uint32
+		tail  [4][2 * chunk]byte // padded final blocks of every lane
+		full  [4]int             // number of complete message blocks
+		total [4]int             // number of blocks including padding
+		p     [4]*byte
+	)
+	for i := range h {
+		h[i] = [4]uint32{iv[i], iv[i], iv[i], iv[i]}
+	}
+	n := -1
+	for l, m := range msgs {
+		full[l] = len(m) / chunk
+		nx := copy(tail[l][:], m[full[l]*chunk:])
+		tail[l][nx] = 0x80
+		t := 1
+		if nx >= chunk-8 {
+			t = 2
+		}
+		binary.BigEndian.PutUint64(tail[l][t*chunk-8:], uint64(len(m))<<3)
+		total[l] = full[l] + t
+		if n < 0 || total[l] < n {
+			n = total[l]
+		}
+	}
+
+	// Run all four lanes together for as long as every lane has a block
+	// left, then finish the longer lanes one at a time.
+	for b := 0; b < n; b++ {
+		for l := range p {
+			if b < full[l] {
+				p[l] = &msgs[l][b*chunk]
+			} else {
+				p[l] = &tail[l][(b-full[l])*chunk]
+			}
+		}
+		block4(&h, &p)
+	}
+
+	for l := range msgs {
+		var d digest
+		for i := range d.h {
+			d.h[i] = h[i][l]
+		}
+		t := 0
+		if n < full[l] {
+			block(&d, msgs[l][n*chunk:full[l]*chunk])
+		} else {
+			t = n - full[l]
+		}
+		block(&d, tail[l][t*chunk:(total[l]-full[l])*chunk])
+		for i, v := range d.h {
+			binary.BigEndian.PutUint32(sums[l][i*4:], v)
+		}
+	}
+	return
+}
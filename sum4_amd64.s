@@ -0,0 +1,340 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build amd64 && !purego && !sm3small
+// +build amd64,!purego,!sm3small
+
+#include "textflag.h"
+
+// Four-way SM3 block routine for amd64 using AVX2.
+//
+// Each 128-bit register holds the same word of four independent messages,
+// one per 32-bit lane. The expanded message W[0..67] is kept on the stack,
+// 16 bytes per word, and the state a..h lives in X0-X7 with the same
+// register role rotation as the scalar routine in sm3block_amd64.s.
+
+// dst = src <<< n, clobbers tmp
+#define ROTL(n, src, dst, tmp) \
+	VPSLLD	$n, src, dst; \
+	VPSRLD	$(32-n), src, tmp; \
+	VPOR	tmp, dst, dst
+
+// Loads four consecutive message words of every lane starting at byte
+// offset off, transposes them so that each register holds one word of all
+// four lanes and byte swaps them into W[index..index+3].
+#define MSGLOAD(off, index) \
+	VMOVDQU	off(R8), X8; \
+	VMOVDQU	off(R9), X9; \
+	VMOVDQU	off(R10), X10; \
+	VMOVDQU	off(R11), X11; \
+	VPUNPCKLDQ	X9, X8, X12; \
+	VPUNPCKHDQ	X9, X8, X13; \
+	VPUNPCKLDQ	X11, X10, X14; \
+	VPUNPCKHDQ	X11, X10, X15; \
+	VPUNPCKLQDQ	X14, X12, X8; \
+	VPUNPCKHQDQ	X14, X12, X9; \
+	VPUNPCKLQDQ	X15, X13, X10; \
+	VPUNPCKHQDQ	X15, X13, X11; \
+	VPSHUFB	bswapMask<>(SB), X8, X8; \
+	VPSHUFB	bswapMask<>(SB), X9, X9; \
+	VPSHUFB	bswapMask<>(SB), X10, X10; \
+	VPSHUFB	bswapMask<>(SB), X11, X11; \
+	VMOVDQU	X8, ((index+0)*16)(SP); \
+	VMOVDQU	X9, ((index+1)*16)(SP); \
+	VMOVDQU	X10, ((index+2)*16)(SP); \
+	VMOVDQU	X11, ((index+3)*16)(SP)
+
+// Wt = P1(Wt-16 ^ Wt-9 ^ (Wt-3 <<< 15)) ^ (Wt-13 <<< 7) ^ Wt-6; for 16 <= t <= 67
+#define MSGSCHEDULE1(index) \
+	VMOVDQU	((index-3)*16)(SP), X9; \
+	ROTL(15, X9, X8, X10); \
+	VPXOR	((index-16)*16)(SP), X8, X8; \
+	VPXOR	((index-9)*16)(SP), X8, X8; \
+	ROTL(15, X8, X9, X10); \
+	ROTL(23, X8, X11, X10); \
+	VPXOR	X9, X8, X8; \
+	VPXOR	X11, X8, X8; \
+	VMOVDQU	((index-13)*16)(SP), X9; \
+	ROTL(7, X9, X11, X10); \
+	VPXOR	X11, X8, X8; \
+	VPXOR	((index-6)*16)(SP), X8, X8; \
+	VMOVDQU	X8, (index*16)(SP)
+
+// Same as SM3ROUND in sm3block_amd64.s, on four lanes. BX points at the
+// table of Tj <<< j. FF and GG leave their result in X12 and may only
+// clobber X12 and X13.
+#define SM3ROUND4(index, a, b, c, d, e, f, g, h, FF, GG) \
+	ROTL(12, a, X8, X9); \
+	VPADDD	e, X8, X9; \
+	VPBROADCASTD	(index*4)(BX), X10; \
+	VPADDD	X10, X9, X9; \
+	ROTL(7, X9, X10, X11); \
+	VPXOR	X10, X8, X8; \
+	VMOVDQU	(index*16)(SP), X9; \
+	VPXOR	((index+4)*16)(SP), X9, X11; \
+	VPADDD	X8, d, d; \
+	VPADDD	X11, d, d; \
+	FF(a, b, c); \
+	VPADDD	X12, d, d; \
+	VPADDD	X10, h, h; \
+	VPADDD	X9, h, h; \
+	GG(e, f, g); \
+	VPADDD	X12, h, h; \
+	ROTL(9, h, X12, X13); \
+	ROTL(17, h, X11, X13); \
+	VPXOR	X12, h, h; \
+	VPXOR	X11, h, h; \
+	ROTL(9, b, X12, X13); \
+	VMOVDQA	X12, b; \
+	ROTL(19, f, X12, X13); \
+	VMOVDQA	X12, f
+
+// x ^ y ^ z
+#define XOR3(x, y, z) \
+	VPXOR	y, x, X12; \
+	VPXOR	z, X12, X12
+
+// (x & y) | ((x | y) & z)
+#define FF1(x, y, z) \
+	VPAND	y, x, X12; \
+	VPOR	y, x, X13; \
+	VPAND	z, X13, X13; \
+	VPOR	X13, X12, X12
+
+// ((y ^ z) & x) ^ z
+#define GG1(x, y, z) \
+	VPXOR	z, y, X12; \
+	VPAND	x, X12, X12; \
+	VPXOR	z, X12, X12
+
+// func block4(h *[8][4]uint32, p *[4]*byte)
+TEXT ·block4(SB), 0, $1088-16
+	MOVQ	h+0(FP), DI
+	MOVQ	p+8(FP), SI
+	MOVQ	(0*8)(SI), R8
+	MOVQ	(1*8)(SI), R9
+	MOVQ	(2*8)(SI), R10
+	MOVQ	(3*8)(SI), R11
+	LEAQ	t4<>(SB), BX
+
+	MSGLOAD(0, 0)
+	MSGLOAD(16, 4)
+	MSGLOAD(32, 8)
+	MSGLOAD(48, 12)
+	MSGSCHEDULE1(16)
+	MSGSCHEDULE1(17)
+	MSGSCHEDULE1(18)
+	MSGSCHEDULE1(19)
+	MSGSCHEDULE1(20)
+	MSGSCHEDULE1(21)
+	MSGSCHEDULE1(22)
+	MSGSCHEDULE1(23)
+	MSGSCHEDULE1(24)
+	MSGSCHEDULE1(25)
+	MSGSCHEDULE1(26)
+	MSGSCHEDULE1(27)
+	MSGSCHEDULE1(28)
+	MSGSCHEDULE1(29)
+	MSGSCHEDULE1(30)
+	MSGSCHEDULE1(31)
+	MSGSCHEDULE1(32)
+	MSGSCHEDULE1(33)
+	MSGSCHEDULE1(34)
+	MSGSCHEDULE1(35)
+	MSGSCHEDULE1(36)
+	MSGSCHEDULE1(37)
+	MSGSCHEDULE1(38)
+	MSGSCHEDULE1(39)
+	MSGSCHEDULE1(40)
+	MSGSCHEDULE1(41)
+	MSGSCHEDULE1(42)
+	MSGSCHEDULE1(43)
+	MSGSCHEDULE1(44)
+	MSGSCHEDULE1(45)
+	MSGSCHEDULE1(46)
+	MSGSCHEDULE1(47)
+	MSGSCHEDULE1(48)
+	MSGSCHEDULE1(49)
+	MSGSCHEDULE1(50)
+	MSGSCHEDULE1(51)
+	MSGSCHEDULE1(52)
+	MSGSCHEDULE1(53)
+	MSGSCHEDULE1(54)
+	MSGSCHEDULE1(55)
+	MSGSCHEDULE1(56)
+	MSGSCHEDULE1(57)
+	MSGSCHEDULE1(58)
+	MSGSCHEDULE1(59)
+	MSGSCHEDULE1(60)
+	MSGSCHEDULE1(61)
+	MSGSCHEDULE1(62)
+	MSGSCHEDULE1(63)
+	MSGSCHEDULE1(64)
+	MSGSCHEDULE1(65)
+	MSGSCHEDULE1(66)
+	MSGSCHEDULE1(67)
+
+	VMOVDQU	(0*16)(DI), X0
+	VMOVDQU	(1*16)(DI), X1
+	VMOVDQU	(2*16)(DI), X2
+	VMOVDQU	(3*16)(DI), X3
+	VMOVDQU	(4*16)(DI), X4
+	VMOVDQU	(5*16)(DI), X5
+	VMOVDQU	(6*16)(DI), X6
+	VMOVDQU	(7*16)(DI), X7
+
+	SM3ROUND4(0, X0, X1, X2, X3, X4, X5, X6, X7, XOR3, XOR3)
+	SM3ROUND4(1, X3, X0, X1, X2, X7, X4, X5, X6, XOR3, XOR3)
+	SM3ROUND4(2, X2, X3, X0, X1, X6, X7, X4, X5, XOR3, XOR3)
+	SM3ROUND4(3, X1, X2, X3, X0, X5, X6, X7, X4, XOR3, XOR3)
+	SM3ROUND4(4, X0, X1, X2, X3, X4, X5, X6, X7, XOR3, XOR3)
+	SM3ROUND4(5, X3, X0, X1, X2, X7, X4, X5, X6, XOR3, XOR3)
+	SM3ROUND4(6, X2, X3, X0, X1, X6, X7, X4, X5, XOR3, XOR3)
+	SM3ROUND4(7, X1, X2, X3, X0, X5, X6, X7, X4, XOR3, XOR3)
+	SM3ROUND4(8, X0, X1, X2, X3, X4, X5, X6, X7, XOR3, XOR3)
+	SM3ROUND4(9, X3, X0, X1, X2, X7, X4, X5, X6, XOR3, XOR3)
+	SM3ROUND4(10, X2, X3, X0, X1, X6, X7, X4, X5, XOR3, XOR3)
+	SM3ROUND4(11, X1, X2, X3, X0, X5, X6, X7, X4, XOR3, XOR3)
+	SM3ROUND4(12, X0, X1, X2, X3, X4, X5, X6, X7, XOR3, XOR3)
+	SM3ROUND4(13, X3, X0, X1, X2, X7, X4, X5, X6, XOR3, XOR3)
+	SM3ROUND4(14, X2, X3, X0, X1, X6, X7, X4, X5, XOR3, XOR3)
+	SM3ROUND4(15, X1, X2, X3, X0, X5, X6, X7, X4, XOR3, XOR3)
+	SM3ROUND4(16, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(17, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(18, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(19, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(20, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(21, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(22, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(23, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(24, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(25, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(26, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(27, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(28, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(29, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(30, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(31, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(32, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(33, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(34, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(35, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(36, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(37, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(38, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(39, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(40, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(41, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(42, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(43, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(44, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(45, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(46, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(47, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(48, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(49, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(50, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(51, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(52, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(53, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(54, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(55, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(56, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(57, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(58, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(59, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+	SM3ROUND4(60, X0, X1, X2, X3, X4, X5, X6, X7, FF1, GG1)
+	SM3ROUND4(61, X3, X0, X1, X2, X7, X4, X5, X6, FF1, GG1)
+	SM3ROUND4(62, X2, X3, X0, X1, X6, X7, X4, X5, FF1, GG1)
+	SM3ROUND4(63, X1, X2, X3, X0, X5, X6, X7, X4, FF1, GG1)
+
+	VPXOR	(0*16)(DI), X0, X0
+	VMOVDQU	X0, (0*16)(DI)
+	VPXOR	(1*16)(DI), X1, X1
+	VMOVDQU	X1, (1*16)(DI)
+	VPXOR	(2*16)(DI), X2, X2
+	VMOVDQU	X2, (2*16)(DI)
+	VPXOR	(3*16)(DI), X3, X3
+	VMOVDQU	X3, (3*16)(DI)
+	VPXOR	(4*16)(DI), X4, X4
+	VMOVDQU	X4, (4*16)(DI)
+	VPXOR	(5*16)(DI), X5, X5
+	VMOVDQU	X5, (5*16)(DI)
+	VPXOR	(6*16)(DI), X6, X6
+	VMOVDQU	X6, (6*16)(DI)
+	VPXOR	(7*16)(DI), X7, X7
+	VMOVDQU	X7, (7*16)(DI)
+
+	VZEROUPPER
+	RET
+
+DATA t4<>+0x00(SB)/4, $0x79cc4519
+DATA t4<>+0x04(SB)/4, $0xf3988a32
+DATA t4<>+0x08(SB)/4, $0xe7311465
+DATA t4<>+0x0c(SB)/4, $0xce6228cb
+DATA t4<>+0x10(SB)/4, $0x9cc45197
+DATA t4<>+0x14(SB)/4, $0x3988a32f
+DATA t4<>+0x18(SB)/4, $0x7311465e
+DATA t4<>+0x1c(SB)/4, $0xe6228cbc
+DATA t4<>+0x20(SB)/4, $0xcc451979
+DATA t4<>+0x24(SB)/4, $0x988a32f3
+DATA t4<>+0x28(SB)/4, $0x311465e7
+DATA t4<>+0x2c(SB)/4, $0x6228cbce
+DATA t4<>+0x30(SB)/4, $0xc451979c
+DATA t4<>+0x34(SB)/4, $0x88a32f39
+DATA t4<>+0x38(SB)/4, $0x11465e73
+DATA t4<>+0x3c(SB)/4, $0x228cbce6
+DATA t4<>+0x40(SB)/4, $0x9d8a7a87
+DATA t4<>+0x44(SB)/4, $0x3b14f50f
+DATA t4<>+0x48(SB)/4, $0x7629ea1e
+DATA t4<>+0x4c(SB)/4, $0xec53d43c
+DATA t4<>+0x50(SB)/4, $0xd8a7a879
+DATA t4<>+0x54(SB)/4, $0xb14f50f3
+DATA t4<>+0x58(SB)/4, $0x629ea1e7
+DATA t4<>+0x5c(SB)/4, $0xc53d43ce
+DATA t4<>+0x60(SB)/4, $0x8a7a879d
+DATA t4<>+0x64(SB)/4, $0x14f50f3b
+DATA t4<>+0x68(SB)/4, $0x29ea1e76
+DATA t4<>+0x6c(SB)/4, $0x53d43cec
+DATA t4<>+0x70(SB)/4, $0xa7a879d8
+DATA t4<>+0x74(SB)/4, $0x4f50f3b1
+DATA t4<>+0x78(SB)/4, $0x9ea1e762
+DATA t4<>+0x7c(SB)/4, $0x3d43cec5
+DATA t4<>+0x80(SB)/4, $0x7a879d8a
+DATA t4<>+0x84(SB)/4, $0xf50f3b14
+DATA t4<>+0x88(SB)/4, $0xea1e7629
+DATA t4<>+0x8c(SB)/4, $0xd43cec53
+DATA t4<>+0x90(SB)/4, $0xa879d8a7
+DATA t4<>+0x94(SB)/4, $0x50f3b14f
+DATA t4<>+0x98(SB)/4, $0xa1e7629e
+DATA t4<>+0x9c(SB)/4, $0x43cec53d
+DATA t4<>+0xa0(SB)/4, $0x879d8a7a
+DATA t4<>+0xa4(SB)/4, $0x0f3b14f5
+DATA t4<>+0xa8(SB)/4, $0x1e7629ea
+DATA t4<>+0xac(SB)/4, $0x3cec53d4
+DATA t4<>+0xb0(SB)/4, $0x79d8a7a8
+DATA t4<>+0xb4(SB)/4, $0xf3b14f50
+DATA t4<>+0xb8(SB)/4, $0xe7629ea1
+DATA t4<>+0xbc(SB)/4, $0xcec53d43
+DATA t4<>+0xc0(SB)/4, $0x9d8a7a87
+DATA t4<>+0xc4(SB)/4, $0x3b14f50f
+DATA t4<>+0xc8(SB)/4, $0x7629ea1e
+DATA t4<>+0xcc(SB)/4, $0xec53d43c
+DATA t4<>+0xd0(SB)/4, $0xd8a7a879
+DATA t4<>+0xd4(SB)/4, $0xb14f50f3
+DATA t4<>+0xd8(SB)/4, $0x629ea1e7
+DATA t4<>+0xdc(SB)/4, $0xc53d43ce
+DATA t4<>+0xe0(SB)/4, $0x8a7a879d
+DATA t4<>+0xe4(SB)/4, $0x14f50f3b
+DATA t4<>+0xe8(SB)/4, $0x29ea1e76
+DATA t4<>+0xec(SB)/4, $0x53d43cec
+DATA t4<>+0xf0(SB)/4, $0xa7a879d8
+DATA t4<>+0xf4(SB)/4, $0x4f50f3b1
+DATA t4<>+0xf8(SB)/4, $0x9ea1e762
+DATA t4<>+0xfc(SB)/4, $0x3d43cec5
+GLOBL t4<>(SB), RODATA, $256
+
+DATA bswapMask<>+0x00(SB)/8, $0x0405060700010203
+DATA bswapMask<>+0x08(SB)/8, $0x0c0d0e0f08090a0b
+GLOBL bswapMask<>(SB), RODATA, $16
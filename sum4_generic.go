@@ -0,0 +1,12 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !amd64 || purego || sm3small
+// +build !amd64 purego sm3small
+
+package sm3
+
+func sum4(msgs [4][]byte) [4][Size]byte {
+	return sum4Generic(msgs)
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestSum4(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	buf := make([]byte, 1024)
+	rng.Read(buf)
+
+	lengths := []int{0, 1, 55, 56, 63, 64, 65, 119, 120, 128, 300, 1024}
+	for _, l0 := range lengths {
+		for trial := 0; trial < 20; trial++ {
+			var msgs [4][]byte
+			msgs[0] = buf[:l0]
+			for i := 1; i < 4; i++ {
+				n := lengths[rng.Intn(len(lengths))]
+				off := rng.Intn(len(buf) - n + 1)
+				msgs[i] = buf[off : off+n]
+			}
+			sums := Sum4(msgs)
+			for i, m := range msgs {
+				if want := Sum(m); sums[i] != want {
+					t.Fatalf("lane %d, len %d: got %x, want %x", i, len(m), sums[i], want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkSum4(b *testing.B) {
+	for _, size := range []int{64, 1024, 8192} {
+		var msgs [4][]byte
+		for i := range msgs {
+			msgs[i] = make([]byte, size)
+		}
+		b.Run(fmt.Sprintf("Sum4/%d", size), func(b *testing.B) {
+			b.SetBytes(4 * int64(size))
+			for i := 0; i < b.N; i++ {
+				Sum4(msgs)
+			}
+		})
+		b.Run(fmt.Sprintf("Sum/%d", size), func(b *testing.B) {
+			b.SetBytes(4 * int64(size))
+			for i := 0; i < b.N; i++ {
+				for _, m := range msgs {
+					Sum(m)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "errors"
+
+// SumTLV returns the SM3 checksum of the DER encoding of value under the
+// identifier octet tag, such as 0x04 for an OCTET STRING or 0x30 for a
+// SEQUENCE: tag, the length of value in definite short or long form, and
+// value itself. Tags needing the multi-octet high-tag-number form are not
+// supported and return an error.
+func SumTLV(tag int, value []byte) ([Size]byte, error) {
+	if tag < 0 || tag > 0xff || tag&0x1f == 0x1f {
+		return [Size]byte{}, errors.New("sm3: TLV tag must be a single identifier octet")
+	}
+
+	var hdr [2 + 8]byte
+	hdr[0] = byte(tag)
+	n := 2
+	if l := uint64(len(value)); l < 0x80 {
+		hdr[1] = byte(l)
+	} else {
+		// Long form: 0x80 | number of length octets, then the length
+		// big-endian in as few octets as possible.
+		for ; l > 0; l >>= 8 {
+			n++
+		}
+		hdr[1] = 0x80 | byte(n-2)
+		l = uint64(len(value))
+		for i := n - 1; i >= 2; i-- {
+			hdr[i] = byte(l)
+			l >>= 8
+		}
+	}
+
+	var d digest
+	d.Reset()
+	d.Write(hdr[:n])
+	d.Write(value)
+	return d.checkSum(), nil
+}
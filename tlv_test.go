@@ -0,0 +1,41 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/asn1"
+	"errors"
+	"testing"
+)
+
+func TestSumTLV(t *testing.T) {
+	for _, n := range []int{0, 1, 127, 128, 255, 256, 65535, 65536} {
+		value := testData(n)
+		der, err := asn1.Marshal(value) // OCTET STRING
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := SumTLV(0x04, value)
+		if err != nil {
+			t.Fatalf("len %d: %v", n, err)
+		}
+		if want := Sum(der); got != want {
+			t.Fatalf("len %d \ngot : %x \nwant: %x", n, got, want)
+		}
+	}
+
+	// A SEQUENCE with a two-octet long-form length, encoded by hand.
+	value := testData(300)
+	der := append([]byte{0x30, 0x82, 0x01, 0x2c}, value...)
+	if got, _ := SumTLV(0x30, value); got != Sum(der) {
+		t.Fatalf("SEQUENCE \ngot : %x \nwant: %x", got, Sum(der))
+	}
+
+	for _, tag := range []int{-1, 0x100, 0x1f, 0xbf} {
+		if _, err := SumTLV(tag, nil); err == nil || errors.Is(err, ErrInvalidLength) {
+			t.Fatalf("SumTLV(%#x): got %v, want a tag error", tag, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// TreeSum returns a two-level tree hash of the first size bytes of r. The
+// input is split into chunkSize byte chunks, the last of which may be
+// shorter, and the result is
+//
+//	SM3(SM3(chunk0) || SM3(chunk1) || ...)
+//
+// with the chunk checksums in input order. An empty input has no chunks, so
+// its tree hash is the SM3 checksum of nothing. Chunks are hashed by up to
+// GOMAXPROCS goroutines reading r concurrently.
+//
+// This is a different function from SM3: its output depends on chunkSize
+// and is not the SM3 checksum of the data.
+func TreeSum(r io.ReaderAt, size int64, chunkSize int) ([Size]byte, error) {
+	if chunkSize <= 0 {
+		return [Size]byte{}, lengthError("tree chunk size must be positive")
+	}
+	if size < 0 {
+		return [Size]byte{}, lengthError("negative tree input size")
+	}
+
+	chunks := int((size + int64(chunkSize) - 1) / int64(chunkSize))
+	sums := make([][Size]byte, chunks)
+
+	// No chunk is longer than the input, so neither is any read buffer.
+	bufSize := int64(chunkSize)
+	if size < bufSize {
+		bufSize = size
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > chunks {
+		workers = chunks
+	}
+
+	var (
+		wg       sync.WaitGroup
+		next     int64 = -1
+		errOnce  sync.Once
+		firstErr error
+	)
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, bufSize)
+			var d digest
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= chunks {
+					return
+				}
+				off := int64(i) * int64(chunkSize)
+				p := buf
+				if rest := size - off; rest < int64(len(p)) {
+					p = p[:rest]
+				}
+				n, err := r.ReadAt(p, off)
+				if n == len(p) {
+					err = nil
+				} else if err == nil || err == io.EOF {
+					err = io.ErrUnexpectedEOF
+				}
+				if err != nil {
+					errOnce.Do(func() { firstErr = err })
+					return
+				}
+				d.Reset()
+				d.Write(p)
+				sums[i] = d.checkSum()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return [Size]byte{}, firstErr
+	}
+
+	var d digest
+	d.Reset()
+	for i := range sums {
+		d.Write(sums[i][:])
+	}
+	return d.checkSum(), nil
+}
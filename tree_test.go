@@ -0,0 +1,90 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"runtime"
+	"testing"
+	"testing/iotest"
+)
+
+func TestTreeSum(t *testing.T) {
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i * 7 % 251)
+	}
+	for _, g := range []struct {
+		size int
+		out  string
+	}{
+		{10000, "4dec6a492d809344ec937c97628fbaa1bea03e7740a43009a06d7d95b064c27e"}, // partial last chunk
+		{100, "ba16aa38e7f64d3e4448faec813f1d95817a7ba18d22adc8439992c1538a9486"},   // single chunk
+		{0, "1ab21d8355cfa17f8e61194831e81a8f22bec8c728fefb747ed035eb5082aa2b"},
+	} {
+		sum, err := TreeSum(bytes.NewReader(data), int64(g.size), 1024)
+		if err != nil {
+			t.Fatalf("size %d: %v", g.size, err)
+		}
+		if s := hex.EncodeToString(sum[:]); s != g.out {
+			t.Fatalf("size %d \ngot : %s \nwant: %s", g.size, s, g.out)
+		}
+	}
+
+	// Exact multiples of the chunk size have no partial chunk.
+	var two [2 * Size]byte
+	for i := 0; i < 2; i++ {
+		s := Sum(data[i*1024 : (i+1)*1024])
+		copy(two[i*Size:], s[:])
+	}
+	want := Sum(two[:])
+	if got, _ := TreeSum(bytes.NewReader(data), 2048, 1024); got != want {
+		t.Fatalf("two chunks: got %x, want %x", got, want)
+	}
+}
+
+func TestTreeSumErrors(t *testing.T) {
+	r := bytes.NewReader(make([]byte, 100))
+	if _, err := TreeSum(r, 100, 0); err == nil {
+		t.Fatal("TreeSum accepted a zero chunk size")
+	}
+	if _, err := TreeSum(r, -1, 10); err == nil {
+		t.Fatal("TreeSum accepted a negative size")
+	}
+	if _, err := TreeSum(r, 200, 10); err != io.ErrUnexpectedEOF {
+		t.Fatalf("TreeSum past the end: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestTreeSumLargeChunk(t *testing.T) {
+	data := []byte("tiny input")
+	want, _ := TreeSum(bytes.NewReader(data), int64(len(data)), len(data))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	got, err := TreeSum(bytes.NewReader(data), int64(len(data)), 1<<30)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("\ngot : %x \nwant: %x", got, want)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Fatalf("TreeSum of %d bytes allocated %d bytes", len(data), n)
+	}
+}
+
+type errReaderAt struct{}
+
+func (errReaderAt) ReadAt(p []byte, off int64) (int, error) { return 0, iotest.ErrTimeout }
+
+func TestTreeSumReadError(t *testing.T) {
+	if _, err := TreeSum(errReaderAt{}, 100, 10); err != iotest.ErrTimeout {
+		t.Fatalf("got %v, want %v", err, iotest.ErrTimeout)
+	}
+}
@@ -0,0 +1,27 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "encoding/gob"
+
+// SumValue returns the SM3 checksum of the gob encoding of v, as produced
+// by a new gob.Encoder. The encoding is streamed through the hash rather
+// than buffered. Any error from encoding v is returned.
+//
+// gob encodes maps in Go's randomized iteration order, so values that
+// contain maps with more than one entry do not hash reproducibly; convert
+// them to sorted slices first. gob also numbers types in the order a
+// process first encodes them, so the same value can hash differently in
+// another process or binary that used other gob types first. The checksum
+// is stable only within one process, suitable for in-memory caches but not
+// for caches shared between processes or for storage.
+func SumValue(v interface{}) ([Size]byte, error) {
+	var d digest
+	d.Reset()
+	if err := gob.NewEncoder(&d).Encode(v); err != nil {
+		return [Size]byte{}, err
+	}
+	return d.checkSum(), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "testing"
+
+type cacheKey struct {
+	Path  string
+	Size  int64
+	Flags []string
+}
+
+func TestSumValue(t *testing.T) {
+	a := cacheKey{"/etc/hosts", 158, []string{"ro"}}
+	b := cacheKey{"/etc/hosts", 158, []string{"ro"}}
+	c := cacheKey{"/etc/hosts", 159, []string{"ro"}}
+
+	sa, err := SumValue(a)
+	if err != nil {
+		t.Fatalf("SumValue: %v", err)
+	}
+	if sb, _ := SumValue(b); sb != sa {
+		t.Fatalf("identical values hash differently: %x and %x", sa, sb)
+	}
+	if sc, _ := SumValue(c); sc == sa {
+		t.Fatal("different values hash identically")
+	}
+	if sp, _ := SumValue(&a); sp != sa {
+		t.Fatal("pointer to a value hashes differently from the value")
+	}
+}
+
+func TestSumValueError(t *testing.T) {
+	if _, err := SumValue(func() {}); err == nil {
+		t.Fatal("SumValue encoded a func")
+	}
+	if _, err := SumValue(make(chan int)); err == nil {
+		t.Fatal("SumValue encoded a channel")
+	}
+}
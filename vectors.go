@@ -0,0 +1,22 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+// The example vectors of the SM3 specification (GB/T 32905-2016 and
+// draft-oscca-cfrg-sm3, appendix A) and the usual long-message vector, as
+// lowercase hex, for interoperability tests.
+const (
+	// VectorABC is the SM3 checksum of "abc", example 1.
+	VectorABC = "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0"
+
+	// VectorABC64 is the SM3 checksum of the 64-byte message "abcd"
+	// repeated 16 times, example 2.
+	VectorABC64 = "debe9ff92275b8a138604889c18e5a4d6fdb70e5387e5765293dcba39c0c5732"
+
+	// VectorMillionA is the SM3 checksum of one million repetitions of
+	// "a". It is not in the specification but is the long-message vector
+	// other implementations commonly test against.
+	VectorMillionA = "c8aaf89429554029e231941a2acc0ad61ff2a5acd8fadd25847a3a732b3b02c3"
+)
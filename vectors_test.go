@@ -0,0 +1,24 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVectors(t *testing.T) {
+	for _, g := range []struct {
+		name, in, out string
+	}{
+		{"abc", "abc", VectorABC},
+		{"abcd*16", strings.Repeat("abcd", 16), VectorABC64},
+		{"a*1000000", strings.Repeat("a", 1000000), VectorMillionA},
+	} {
+		if s := SumHex([]byte(g.in)); s != g.out {
+			t.Fatalf("sm3(%s) \ngot : %s \nwant: %s", g.name, s, g.out)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import "crypto/subtle"
+
+// Equal reports whether a and b are equal, in time independent of their
+// contents. Comparing digests with == stops at the first differing byte,
+// which lets an attacker who controls one side and can measure the time
+// taken learn a secret digest, such as a MAC, byte by byte. Prefer Equal
+// whenever either value is secret.
+func Equal(a, b [Size]byte) bool {
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+// SameDigest reports, in constant time, whether a and b have the same SM3
+// checksum, without exposing the checksums to the caller.
+func SameDigest(a, b []byte) bool {
+	return Equal(Sum(a), Sum(b))
+}
+
+// A Verifier checks that the data written to it has an expected SM3
+// checksum.
+type Verifier struct {
+	d        digest
+	expected [Size]byte
+}
+
+// NewVerifier returns a Verifier for data whose checksum should be
+// expected.
+func NewVerifier(expected [Size]byte) *Verifier {
+	v := &Verifier{expected: expected}
+	v.d.Reset()
+	return v
+}
+
+// Write adds p to the data being verified. It never returns an error.
+func (v *Verifier) Write(p []byte) (int, error) {
+	return v.d.Write(p)
+}
+
+// Verified reports, in constant time, whether the data written so far has
+// the expected checksum.
+func (v *Verifier) Verified() bool {
+	return Equal(v.d.checkSum(), v.expected)
+}
+
+// VerifyHex reports, in constant time, whether the SM3 checksum of data is
+// expectedHex, 64 hex digits in either case. It returns an error if
+// expectedHex is not a well-formed digest.
+func VerifyHex(data []byte, expectedHex string) (bool, error) {
+	var want Digest
+	if err := want.UnmarshalText([]byte(expectedHex)); err != nil {
+		return false, err
+	}
+	return Equal(Sum(data), want), nil
+}
+
+// VerifyHMAC reports whether tag is the HMAC-SM3 of data under key. The
+// comparison always covers all Size bytes of the expected MAC, so a tag of
+// the wrong length is rejected without revealing how much of it matched.
+func VerifyHMAC(key, data, tag []byte) bool {
+	return verifyHMAC(key, data, tag) == 1
+}
+
+// verifyHMAC is VerifyHMAC returning 1 for a match and 0 otherwise.
+func verifyHMAC(key, data, tag []byte) int {
+	return tagEqual(SumHMAC(key, data), tag)
+}
+
+// tagEqual returns 1 if tag is mac and 0 otherwise, comparing all Size bytes
+// of mac whatever the length of tag.
+func tagEqual(mac [Size]byte, tag []byte) int {
+	var t [Size]byte
+	copy(t[:], tag)
+	ok := subtle.ConstantTimeCompare(mac[:], t[:])
+	return ok & subtle.ConstantTimeEq(int32(len(tag)), Size)
+}
+
+// VerifyHMACAny reports whether tag is the HMAC-SM3 of data under any of
+// keys, and if so returns the index of the first such key. It computes the
+// MAC under every key whatever matches, so its running time reveals only
+// the number of keys but grows linearly with it; keep the key set small,
+// such as the current and previous keys during a rotation.
+func VerifyHMACAny(keys [][]byte, data, tag []byte) (int, bool) {
+	idx, found := -1, 0
+	for i, key := range keys {
+		ok := verifyHMAC(key, data, tag)
+		idx = subtle.ConstantTimeSelect(ok&^found, i, idx)
+		found |= ok
+	}
+	return idx, found == 1
+}
+
+// An HMACItem is a message and its purported HMAC-SM3 tag.
+type HMACItem struct {
+	Data, Tag []byte
+}
+
+// VerifyHMACBatch reports for each item whether its Tag is the HMAC-SM3 of
+// its Data under key, like VerifyHMAC, but derives the key schedule only
+// once for the whole batch.
+func VerifyHMACBatch(key []byte, items []HMACItem) []bool {
+	var k HMACKey
+	k.init(key)
+	ok := make([]bool, len(items))
+	for i, it := range items {
+		ok[i] = tagEqual(k.Sum(it.Data), it.Tag) == 1
+	}
+	return ok
+}
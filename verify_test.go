@@ -0,0 +1,204 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEqual(t *testing.T) {
+	a := Sum([]byte("abc"))
+	b := a
+	if !Equal(a, b) {
+		t.Fatal("Equal returned false for equal digests")
+	}
+	b[Size-1] ^= 1
+	if Equal(a, b) {
+		t.Fatal("Equal returned true for digests differing in the last byte")
+	}
+	b = a
+	b[0] ^= 0x80
+	if Equal(a, b) {
+		t.Fatal("Equal returned true for digests differing in the first byte")
+	}
+}
+
+func TestSameDigest(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "abd", false},
+		{"", "", true},
+		{"", "a", false},
+	} {
+		if got := SameDigest([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Fatalf("SameDigest(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+	if !SameDigest(nil, []byte{}) {
+		t.Fatal("nil and empty inputs differ")
+	}
+}
+
+func TestVerifyHex(t *testing.T) {
+	abc := []byte("abc")
+	for _, tt := range []struct {
+		hex  string
+		ok   bool
+		fail bool
+	}{
+		{golden[1].out, true, false},
+		{strings.ToUpper(golden[1].out), true, false},
+		{golden[0].out, false, false},
+		{golden[1].out[:62], false, true},
+		{golden[1].out + "00", false, true},
+		{"zz" + golden[1].out[2:], false, true},
+		{"", false, true},
+	} {
+		ok, err := VerifyHex(abc, tt.hex)
+		if ok != tt.ok || (err != nil) != tt.fail {
+			t.Fatalf("VerifyHex(%q) = %v, %v; want %v, error %v", tt.hex, ok, err, tt.ok, tt.fail)
+		}
+	}
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	key, data := []byte("webhook secret"), []byte(`{"event":"push"}`)
+	tag := SumHMAC(key, data)
+	if !VerifyHMAC(key, data, tag[:]) {
+		t.Fatal("VerifyHMAC rejected a valid tag")
+	}
+
+	bad := tag
+	bad[7] ^= 1
+	for _, tt := range []struct {
+		name string
+		tag  []byte
+	}{
+		{"tampered", bad[:]},
+		{"truncated", tag[:Size-1]},
+		{"extended", append(tag[:], 0)},
+		{"empty", nil},
+		{"zero", make([]byte, Size)},
+	} {
+		if VerifyHMAC(key, data, tt.tag) {
+			t.Fatalf("VerifyHMAC accepted a %s tag", tt.name)
+		}
+	}
+	if VerifyHMAC([]byte("other key"), data, tag[:]) {
+		t.Fatal("VerifyHMAC accepted a tag under the wrong key")
+	}
+}
+
+func TestVerifyHMACAny(t *testing.T) {
+	keys := [][]byte{[]byte("current"), []byte("previous"), []byte("older"), []byte("previous")}
+	data := []byte("message")
+	for want := 0; want < 3; want++ {
+		tag := SumHMAC(keys[want], data)
+		i, ok := VerifyHMACAny(keys, data, tag[:])
+		if !ok || i != want {
+			t.Fatalf("tag under key %d: got %d, %v", want, i, ok)
+		}
+	}
+	tag := SumHMAC([]byte("unknown"), data)
+	if i, ok := VerifyHMACAny(keys, data, tag[:]); ok || i != -1 {
+		t.Fatalf("unknown key: got %d, %v; want -1, false", i, ok)
+	}
+	if i, ok := VerifyHMACAny(nil, data, tag[:]); ok || i != -1 {
+		t.Fatalf("no keys: got %d, %v; want -1, false", i, ok)
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	data := testData(5000)
+	v := NewVerifier(Sum(data))
+	if v.Verified() {
+		t.Fatal("Verified before any data was written")
+	}
+	if _, err := io.Copy(v, iotest.OneByteReader(bytes.NewReader(data))); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Verified() {
+		t.Fatal("Verified returned false for matching data")
+	}
+	v.Write([]byte{0})
+	if v.Verified() {
+		t.Fatal("Verified returned true after extra data")
+	}
+
+	data[100] ^= 1
+	v = NewVerifier(Sum(data[:1000]))
+	v.Write(data[:500])
+	v.Write(data[500:1000])
+	if !v.Verified() {
+		t.Fatal("Verified returned false over multiple writes")
+	}
+	v = NewVerifier(Sum(testData(5000)))
+	v.Write(data)
+	if v.Verified() {
+		t.Fatal("Verified returned true for corrupted data")
+	}
+}
+
+func TestVerifyHMACBatch(t *testing.T) {
+	key := []byte("webhook key")
+	valid := func(data string) HMACItem {
+		tag := SumHMAC(key, []byte(data))
+		return HMACItem{[]byte(data), tag[:]}
+	}
+	forged := valid("forged")
+	forged.Tag = append([]byte(nil), forged.Tag...)
+	forged.Tag[0] ^= 1
+	otherKey := SumHMAC([]byte("other key"), []byte("event"))
+	items := []HMACItem{
+		valid("event 1"),
+		forged,
+		valid("event 2"),
+		{[]byte("event"), otherKey[:]},
+		{[]byte("short"), valid("short").Tag[:Size-1]},
+		valid(""),
+	}
+	want := []bool{true, false, true, false, false, true}
+	got := VerifyHMACBatch(key, items)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if len(VerifyHMACBatch(key, nil)) != 0 {
+		t.Fatal("empty batch gave results")
+	}
+}
+
+func benchmarkHMACItems() []HMACItem {
+	items := make([]HMACItem, 64)
+	for i := range items {
+		tag := SumHMAC(hmacBenchKey, buf[:i])
+		items[i] = HMACItem{buf[:i], tag[:]}
+	}
+	return items
+}
+
+func BenchmarkVerifyHMACBatch(b *testing.B) {
+	items := benchmarkHMACItems()
+	for i := 0; i < b.N; i++ {
+		VerifyHMACBatch(hmacBenchKey, items)
+	}
+}
+
+func BenchmarkVerifyHMACEach(b *testing.B) {
+	items := benchmarkHMACItems()
+	for i := 0; i < b.N; i++ {
+		for _, it := range items {
+			VerifyHMAC(hmacBenchKey, it.Data, it.Tag)
+		}
+	}
+}
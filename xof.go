@@ -0,0 +1,52 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+type xof struct {
+	seed    digest // state after absorbing the seed
+	counter uint64
+	buf     [Size]byte
+	off     int // bytes of buf already returned
+}
+
+// NewXOF returns a reader of the pseudorandom stream
+//
+//	SM3(seed || C0) || SM3(seed || C1) || ...
+//
+// where C is a 64-bit big-endian counter starting at 0. This is a
+// counter-mode expansion in the style of a KDF, not a standardized
+// extendable-output function, and the stream repeats after 2^64 blocks.
+func NewXOF(seed []byte) io.Reader {
+	x := &xof{off: Size}
+	x.seed.Reset()
+	x.seed.Write(seed)
+	return x
+}
+
+// Read fills p with the next len(p) bytes of the stream. It never returns
+// an error.
+func (x *xof) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if x.off == Size {
+			d := x.seed
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], x.counter)
+			d.Write(c[:])
+			d.checkSumInto(&x.buf)
+			x.counter++
+			x.off = 0
+		}
+		m := copy(p[n:], x.buf[x.off:])
+		x.off += m
+		n += m
+	}
+	return n, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sm3
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+)
+
+func TestXOF(t *testing.T) {
+	const want = "8f417a9c0e813eb2684a0b3cdff28a7bfbb182820eb71fcbdd108420482bbb72" +
+		"71536e796c66f63ccfb3db59d0efd8f46ad73e3bfd97da6130e62a3a8113bc32" +
+		"f18eb4ffbcfb695d7df5f9914385f1fe2a1ddd3d84949c8e282dccfda58286d1" +
+		"14c35e83"
+	out := make([]byte, len(want)/2)
+	io.ReadFull(NewXOF([]byte("seed")), out)
+	if s := hex.EncodeToString(out); s != want {
+		t.Fatalf("NewXOF \ngot : %s \nwant: %s", s, want)
+	}
+
+	for _, chunk := range []int{1, 7, 31, 32, 33, 64, 99} {
+		r := NewXOF([]byte("seed"))
+		var got []byte
+		buf := make([]byte, chunk)
+		for len(got) < len(out) {
+			n, err := r.Read(buf)
+			if n != chunk || err != nil {
+				t.Fatalf("chunk %d: Read = %d, %v", chunk, n, err)
+			}
+			got = append(got, buf...)
+		}
+		if !bytes.Equal(got[:len(out)], out) {
+			t.Fatalf("chunk %d: stream differs", chunk)
+		}
+	}
+}